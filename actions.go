@@ -0,0 +1,245 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// Delete deletes all messages in the folder matching crit.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) Delete(expunge bool, folder Folder, crit criteria.Criteria) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	uids, err := matchingUIDs(b, crit)
+	if err != nil {
+		return res, err
+	}
+	res.Matched = len(uids)
+
+	delUIDs := new(imap.SeqSet)
+	delUIDs.AddNum(uids...)
+	if err := includeThreads(b, &res, delUIDs); err != nil {
+		return res, err
+	}
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// Move moves all messages in src matching crit to dest and returns the number of moved messages.
+// Servers without MOVE return ErrCapabilityMissing instead of a copy and expunge.
+func (b *Inbox) Move(src, dest Folder, crit criteria.Criteria) (int, error) {
+	defer operation(b)()
+	return transfer(b, src, dest, crit, func(uids *imap.SeqSet, dest string) error {
+		if err := requireCapability(b, "MOVE"); err != nil {
+			return err
+		}
+
+		return conn(b).UidMove(uids, dest)
+	})
+}
+
+// Copy copies all messages in src matching crit to dest and returns the number of copied messages.
+func (b *Inbox) Copy(src, dest Folder, crit criteria.Criteria) (int, error) {
+	defer operation(b)()
+	return transfer(b, src, dest, crit, conn(b).UidCopy)
+}
+
+// transfer applies op to the UIDs of all messages in src matching crit.
+func transfer(b *Inbox, src, dest Folder, crit criteria.Criteria, op func(*imap.SeqSet, string) error) (int, error) {
+	if _, err := selectFolder(b, src); err != nil {
+		return 0, err
+	}
+
+	uids, err := matchingUIDs(b, crit)
+	if err != nil || len(uids) == 0 {
+		return 0, err
+	}
+
+	uidSet := new(imap.SeqSet)
+	uidSet.AddNum(uids...)
+	if skipMutation(b, "transfer", uidSet, "from", src, "to", dest) {
+		return len(uids), nil
+	}
+
+	dest, err = serverFolder(b, dest)
+	if err != nil {
+		return 0, err
+	}
+	defer invalidateSelected(b)
+
+	if err := op(uidSet, string(dest)); err != nil {
+		return 0, err
+	}
+
+	return len(uids), nil
+}
+
+// MoveRange moves the messages with sequence numbers start to end (inclusive) from src to dest and returns
+// the number of moved messages. end is clamped to the number of messages in src, so MoveRange(src, dest, 1, 500)
+// moves the oldest 500 messages. Servers without MOVE return ErrCapabilityMissing.
+func (b *Inbox) MoveRange(src, dest Folder, start, end uint32) (int, error) {
+	defer operation(b)()
+	if start == 0 || start > end {
+		return 0, fmt.Errorf("inbox: invalid sequence range %d:%d", start, end)
+	}
+
+	mbox, err := selectFolder(b, src)
+	if err != nil {
+		return 0, err
+	}
+
+	end = min(end, mbox.Messages)
+	if start > end {
+		return 0, nil
+	}
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddRange(start, end)
+	if skipMutation(b, "move messages", seqSet, "from", src, "to", dest) {
+		return int(end - start + 1), nil
+	}
+
+	if err := requireCapability(b, "MOVE"); err != nil {
+		return 0, err
+	}
+
+	dest, err = serverFolder(b, dest)
+	if err != nil {
+		return 0, err
+	}
+	defer invalidateSelected(b)
+
+	if err := conn(b).Move(seqSet, string(dest)); err != nil {
+		return 0, err
+	}
+
+	log.Println("Moved messages", seqSet, "from", src, "to", dest)
+	return int(end - start + 1), nil
+}
+
+// DeleteUIDRange deletes the messages with UIDs start to end (inclusive) in the folder. Unlike sequence numbers,
+// UIDs stay valid as long as the UIDVALIDITY of the folder does, e.g. UIDs exported by other tools. The range
+// may be sparse, Matched is the number of UIDs in it which existed.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteUIDRange(expunge bool, folder Folder, start, end uint32) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if start == 0 || start > end {
+		return res, fmt.Errorf("inbox: invalid UID range %d:%d", start, end)
+	}
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	uidSet := new(imap.SeqSet)
+	uidSet.AddRange(start, end)
+	search := imap.NewSearchCriteria()
+	search.Uid = uidSet
+	uids, err := conn(b).UidSearch(search)
+	if err != nil {
+		return res, err
+	}
+
+	delUIDs := new(imap.SeqSet)
+	for _, uid := range uids {
+		if uid >= start && uid <= end {
+			delUIDs.AddNum(uid)
+			res.Matched++
+		}
+	}
+	log.Println("Messages to delete in", folder, "with UIDs", uidSet.String()+":", res.Matched)
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	return res, err
+}
+
+// DeleteMatchingAny deletes all messages in the folder matching at least one of crits with a single SEARCH, STORE
+// and EXPUNGE, see criteria.Or. A message matched by several criteria is counted and deleted once.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteMatchingAny(expunge bool, folder Folder, crits ...criteria.Criteria) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	uids, err := matchingUIDs(b, criteria.Or(crits...))
+	if err != nil {
+		return res, err
+	}
+	res.Matched = len(uids)
+
+	delUIDs := new(imap.SeqSet)
+	delUIDs.AddNum(uids...)
+
+	if err := includeThreads(b, &res, delUIDs); err != nil {
+		return res, err
+	}
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	return res, err
+}
+
+// DeleteBounces deletes the delivery status notifications in the folder received more than olderThan ago,
+// see criteria.IsBounce. When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteBounces(expunge bool, folder Folder, olderThan time.Duration) (DeleteResult, error) {
+	defer operation(b)()
+	return b.Delete(expunge, folder, criteria.And(criteria.IsBounce(), criteria.OlderThan(olderThan)))
+}
+
+// DeleteMessagesFromListID deletes the mailing list messages in the folder whose List-Id is one of listIDs,
+// see criteria.ListID. When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteMessagesFromListID(expunge bool, folder Folder, listIDs ...string) (DeleteResult, error) {
+	defer operation(b)()
+	return b.Delete(expunge, folder, criteria.ListID(listIDs...))
+}
+
+// DeleteAutoReplies deletes the automatic replies in the folder received more than olderThan ago,
+// see criteria.IsAutoReply. When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteAutoReplies(expunge bool, folder Folder, olderThan time.Duration) (DeleteResult, error) {
+	defer operation(b)()
+	return b.Delete(expunge, folder, criteria.And(criteria.IsAutoReply(), criteria.OlderThan(olderThan)))
+}
+
+// DeleteMessagesFailingAuth deletes the messages in the folder whose Authentication-Results report a failed
+// check, see criteria.AuthFailed. checks are "spf", "dkim" and "dmarc", all of them count when none are given.
+// Messages without the header are kept. When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteMessagesFailingAuth(expunge bool, folder Folder, checks ...string) (DeleteResult, error) {
+	defer operation(b)()
+	return b.Delete(expunge, folder, criteria.AuthFailed(checks...))
+}
@@ -0,0 +1,50 @@
+package inbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+func TestMoveWithoutMoveCapability(t *testing.T) {
+	s := newTestServer(t)
+	s.mailbox(t, "Other")
+	first := s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	last := s.addMessage(t, "Archive", "b@example.com", "second", time.Now())
+	b := s.dial(t)
+	if supports(b, "MOVE") {
+		t.Skip("test server supports MOVE")
+	}
+	s.log.Reset()
+
+	ops := map[string]func() error{
+		"Move": func() error {
+			_, err := b.Move("Archive", "Other", criteria.FromAny("a@example.com"))
+			return err
+		},
+		"MoveRange": func() error {
+			_, err := b.MoveRange("Archive", "Other", first, last)
+			return err
+		},
+	}
+
+	for name, op := range ops {
+		want := ErrCapabilityMissing{Capability: "MOVE"}
+		if err := op(); !errors.Is(err, want) {
+			t.Errorf("%s without MOVE: err %v, want %v", name, err, want)
+		}
+		for _, cmd := range s.commands() {
+			switch cmd {
+			case "COPY", "UID COPY", "STORE", "UID STORE", "EXPUNGE", "UID EXPUNGE":
+				t.Errorf("%s fell back to %s", name, cmd)
+			}
+		}
+		s.log.Reset()
+	}
+
+	if n := len(s.mailbox(t, "Archive").Messages); n != 2 {
+		t.Errorf("Archive holds %d messages, want 2", n)
+	}
+}
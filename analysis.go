@@ -0,0 +1,249 @@
+package inbox
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// maxExampleSubjects is the number of example subjects kept per sender.
+const maxExampleSubjects = 3
+
+// Suggestion is a sender which is a candidate for the blocklist.
+type Suggestion struct {
+	Address string
+	// Messages is the number of messages from the sender.
+	Messages int
+	// Seen is the number of those messages which were opened.
+	Seen int
+	// ReadRatio is Seen divided by Messages.
+	ReadRatio float64
+	// Subjects holds a few example subjects.
+	Subjects []string
+}
+
+// SuggestBlockCandidates returns senders with at least minCount messages in the folder of which at most
+// maxReadRatio were read, most frequent first. The folder is examined read-only, no flags are changed.
+func (b *Inbox) SuggestBlockCandidates(folder Folder, minCount int, maxReadRatio float64) ([]Suggestion, error) {
+	defer operation(b)()
+	mbox, err := examineFolder(b, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchEnvelope, imap.FetchFlags)
+	}()
+
+	senders := make(map[string]*Suggestion)
+	for msg := range messages {
+		if msg.Envelope == nil {
+			continue
+		}
+
+		for _, from := range msg.Envelope.From {
+			addr := strings.ToLower(from.Address())
+			s, ok := senders[addr]
+			if !ok {
+				s = &Suggestion{Address: addr}
+				senders[addr] = s
+			}
+
+			s.Messages++
+			if hasFlag(msg, imap.SeenFlag) {
+				s.Seen++
+			}
+			if len(s.Subjects) < maxExampleSubjects {
+				s.Subjects = append(s.Subjects, criteria.DecodeHeader(msg.Envelope.Subject))
+			}
+		}
+	}
+
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+	observeFetch(b, folder, int(mbox.Messages))
+
+	var suggestions []Suggestion
+	for _, s := range senders {
+		s.ReadRatio = float64(s.Seen) / float64(s.Messages)
+		if s.Messages >= minCount && s.ReadRatio <= maxReadRatio {
+			suggestions = append(suggestions, *s)
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Messages != suggestions[j].Messages {
+			return suggestions[i].Messages > suggestions[j].Messages
+		}
+		return suggestions[i].Address < suggestions[j].Address
+	})
+
+	return suggestions, nil
+}
+
+// hasFlag reports whether the message carries the given flag.
+func hasFlag(msg *imap.Message, flag string) bool {
+	for _, f := range msg.Flags {
+		if strings.EqualFold(f, flag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AddressesPresent returns how many messages in the folder were sent from each of the given addresses, zero for
+// addresses without any message. The folder is examined read-only, so it's safe to prune a blocklist with it.
+func (b *Inbox) AddressesPresent(folder Folder, addr ...string) (map[string]int, error) {
+	defer operation(b)()
+	addr, err := normalizeAddresses(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mbox, err := examineFolder(b, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchUid, imap.FetchEnvelope)
+	}()
+
+	_, msgMap := compare(b, addr, messages, new(imap.SeqSet))
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+	observeFetch(b, folder, int(mbox.Messages))
+
+	present := make(map[string]int, len(addr))
+	for _, a := range addr {
+		present[a] = len(msgMap[a])
+	}
+
+	return present, nil
+}
+
+// SenderCount is the number of messages of a single sender.
+type SenderCount struct {
+	Address string
+	// Name is the display name of the sender's most recent message.
+	Name  string
+	Count int
+}
+
+// SenderFrequency returns the senders of all messages received since the given time, most frequent first.
+// The folder is examined read-only.
+func (b *Inbox) SenderFrequency(folder Folder, since time.Time) ([]SenderCount, error) {
+	defer operation(b)()
+	if _, err := examineFolder(b, folder); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]*SenderCount)
+	fetched := 0
+	err := findMessages(b, criteria.Since(since), []imap.FetchItem{imap.FetchEnvelope}, func(msg *imap.Message) bool {
+		fetched++
+		if msg.Envelope == nil {
+			return true
+		}
+
+		for _, from := range msg.Envelope.From {
+			addr := strings.ToLower(from.Address())
+			c, ok := counts[addr]
+			if !ok {
+				c = &SenderCount{Address: addr}
+				counts[addr] = c
+			}
+
+			c.Count++
+			if name := criteria.DecodeHeader(from.PersonalName); name != "" {
+				c.Name = name
+			}
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	observeFetch(b, folder, fetched)
+
+	ranking := make([]SenderCount, 0, len(counts))
+	for _, c := range counts {
+		ranking = append(ranking, *c)
+	}
+
+	sort.Slice(ranking, func(i, j int) bool {
+		if ranking[i].Count != ranking[j].Count {
+			return ranking[i].Count > ranking[j].Count
+		}
+		return ranking[i].Address < ranking[j].Address
+	})
+
+	return ranking, nil
+}
+
+// Age buckets of AgeHistogram, by the time the server received the messages.
+const (
+	// AgeWeek counts the messages of the last 7 days.
+	AgeWeek = "week"
+	// AgeMonth counts the messages of the last 30 days, excluding the last week.
+	AgeMonth = "month"
+	// AgeYear counts the messages of the last 365 days, excluding the last month.
+	AgeYear = "year"
+	// AgeOlder counts all older messages.
+	AgeOlder = "older"
+)
+
+// AgeHistogram counts the messages in the folder per age bucket, AgeWeek, AgeMonth, AgeYear and AgeOlder. It
+// shows how much a retention rule like OlderThan would remove. Only the internal dates are fetched and the
+// folder is examined read-only.
+func (b *Inbox) AgeHistogram(folder Folder) (map[string]int, error) {
+	defer operation(b)()
+	mbox, err := examineFolder(b, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchInternalDate)
+	}()
+
+	now := now(b)
+	histogram := map[string]int{AgeWeek: 0, AgeMonth: 0, AgeYear: 0, AgeOlder: 0}
+	for msg := range messages {
+		histogram[ageBucket(now.Sub(msg.InternalDate))]++
+	}
+	if err := <-errChan; err != nil {
+		return nil, err
+	}
+	observeFetch(b, folder, int(mbox.Messages))
+
+	return histogram, nil
+}
+
+// ageBucket returns the AgeHistogram bucket of a message of the given age.
+func ageBucket(age time.Duration) string {
+	const day = 24 * time.Hour
+	switch {
+	case age < 7*day:
+		return AgeWeek
+	case age < 30*day:
+		return AgeMonth
+	case age < 365*day:
+		return AgeYear
+	default:
+		return AgeOlder
+	}
+}
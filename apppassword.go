@@ -0,0 +1,63 @@
+package inbox
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrAuthentication is returned when the server rejects the credentials.
+var ErrAuthentication = errors.New("inbox: authentication failed")
+
+// ErrAppPasswordRequired is returned when the server rejects the account password because an app password has to
+// be used instead, typically with two-factor authentication enabled. It matches ErrAuthentication with errors.Is.
+type ErrAppPasswordRequired struct {
+	// Text is the server's own wording.
+	Text string
+	// HelpURL is the page the server pointed to, empty if it didn't.
+	HelpURL string
+}
+
+func (e ErrAppPasswordRequired) Error() string {
+	msg := "inbox: the server requires an app password instead of the account password"
+	if e.HelpURL != "" {
+		msg += ", see " + e.HelpURL
+	}
+
+	return msg
+}
+
+func (e ErrAppPasswordRequired) Is(target error) bool {
+	return target == ErrAuthentication
+}
+
+// AppPasswordTexts holds, per provider, parts of the login responses telling that an app password is required.
+// The texts of the empty provider apply to every server. Entries may be added for further providers.
+var AppPasswordTexts = map[ImapProvider][]string{
+	"":    {"application-specific password", "app password"},
+	GMX:   {"anwendungsspezifisch", "app-passwort"},
+	Gmail: {"application-specific password required"},
+}
+
+// helpURLPattern finds the help URL in the text of a login response. go-imap drops response codes like WEBALERT,
+// so URLs only given there can't be reported.
+var helpURLPattern = regexp.MustCompile(`https?://[^\s\])]+`)
+
+// asAppPasswordRequired returns ErrAppPasswordRequired if err is a refused login asking for an app password.
+func asAppPasswordRequired(provider ImapProvider, err error) error {
+	if !errors.Is(err, ErrAuthentication) {
+		return err
+	}
+
+	text := strings.TrimPrefix(err.Error(), ErrAuthentication.Error()+": ")
+	lower := strings.ToLower(text)
+	for _, p := range []ImapProvider{"", provider} {
+		for _, t := range AppPasswordTexts[p] {
+			if strings.Contains(lower, t) {
+				return ErrAppPasswordRequired{Text: text, HelpURL: helpURLPattern.FindString(text)}
+			}
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+package inbox
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// AttachmentResult is the outcome of DeleteWithAttachmentTypes.
+type AttachmentResult struct {
+	DeleteResult
+	// Attachments are the attachments which matched, by UID of their message. Attachments are described by
+	// their file name or, without one, their MIME type.
+	Attachments map[uint32][]string
+}
+
+// DeleteWithAttachmentTypes deletes the messages in the folder with an attachment matching one of the patterns,
+// like "*.exe", "*.js" or "application/x-msdownload". Patterns containing a "/" are MIME types, see
+// criteria.AttachmentType, all others file name globs, see criteria.AttachmentName.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteWithAttachmentTypes(expunge bool, folder Folder, patterns []string) (res AttachmentResult, err error) {
+	defer operation(b)()
+	res = AttachmentResult{DeleteResult: DeleteResult{Folder: folder}, Attachments: make(map[uint32][]string)}
+	defer func(start time.Time) { observeDelete(b, start, &res.DeleteResult, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	delUIDs := new(imap.SeqSet)
+	items := []imap.FetchItem{imap.FetchEnvelope, imap.FetchBodyStructure}
+	err = findMessages(b, criteria.All(), items, func(msg *imap.Message) bool {
+		matches := criteria.MatchingAttachments(msg.BodyStructure, patterns...)
+		if len(matches) == 0 {
+			return true
+		}
+
+		var subject string
+		if msg.Envelope != nil {
+			subject = criteria.DecodeHeader(msg.Envelope.Subject)
+		}
+		log.Println("\t", subject+":", strings.Join(matches, ", "))
+		res.Attachments[msg.Uid] = matches
+		delUIDs.AddNum(msg.Uid)
+		res.Matched++
+		return true
+	})
+	if err != nil {
+		return res, err
+	}
+	log.Println("Messages with matching attachments to delete in", folder+":", res.Matched)
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res.DeleteResult)
+	return res, err
+}
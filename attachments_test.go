@@ -0,0 +1,65 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+)
+
+// envelopelessBackend answers FETCH without the envelopes, like servers failing to parse a message's header.
+type envelopelessBackend struct {
+	backend.Backend
+}
+
+func (be envelopelessBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := be.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelopelessUser{u}, nil
+}
+
+type envelopelessUser struct {
+	backend.User
+}
+
+func (u envelopelessUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelopelessMailbox{mbox}, nil
+}
+
+type envelopelessMailbox struct {
+	backend.Mailbox
+}
+
+func (m envelopelessMailbox) ListMessages(uid bool, seqSet *imap.SeqSet, items []imap.FetchItem, ch chan<- *imap.Message) error {
+	var kept []imap.FetchItem
+	for _, item := range items {
+		if item != imap.FetchEnvelope {
+			kept = append(kept, item)
+		}
+	}
+
+	return m.Mailbox.ListMessages(uid, seqSet, kept, ch)
+}
+
+func TestDeleteWithAttachmentTypesWithoutEnvelope(t *testing.T) {
+	s := newTestServer(t, func(be backend.Backend) backend.Backend { return envelopelessBackend{be} })
+	uid := s.addMessage(t, "Archive", "a@example.com", "plain", time.Now())
+	b := s.dial(t)
+
+	res, err := b.DeleteWithAttachmentTypes(false, "Archive", []string{"text/plain"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matched != 1 || len(res.Attachments[uid]) != 1 {
+		t.Errorf("Matched %d, Attachments %v, want the message with UID %d", res.Matched, res.Attachments, uid)
+	}
+}
@@ -0,0 +1,82 @@
+package inbox
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// SetAuditLog appends a JSON line for every permanently deleted message to the file at path. Failing to write
+// the audit log is logged, but doesn't stop the deletion. An empty path disables the audit log.
+func (b *Inbox) SetAuditLog(path string) {
+	b.auditPath = path
+}
+
+// auditEntry is a line of the audit log.
+type auditEntry struct {
+	Time      time.Time `json:"time"`
+	Folder    Folder    `json:"folder"`
+	UID       uint32    `json:"uid"`
+	MessageID string    `json:"messageId"`
+	From      string    `json:"from"`
+	Subject   string    `json:"subject"`
+}
+
+// auditEntries fetches the details of the messages about to be deleted, nil without audit log.
+func auditEntries(b *Inbox, uids *imap.SeqSet) map[uint32]auditEntry {
+	if b.auditPath == "" {
+		return nil
+	}
+
+	var folder Folder
+	if mbox := currentClient(b).Mailbox(); mbox != nil {
+		folder = logicalFolder(b, mbox.Name)
+	}
+
+	entries := make(map[uint32]auditEntry)
+	err := fetchEach(b, uids, []imap.FetchItem{imap.FetchEnvelope}, func(msg *imap.Message) {
+		s := newSummary(folder, msg)
+		entries[msg.Uid] = auditEntry{Folder: folder, UID: msg.Uid, MessageID: s.MessageID, From: s.FromAddress, Subject: s.Subject}
+	})
+	if err != nil {
+		log.Println("Fetching details for the audit log failed:", err)
+	}
+
+	return entries
+}
+
+// writeAudit appends the entries of the expunged UIDs to the audit log and syncs it.
+func writeAudit(b *Inbox, entries map[uint32]auditEntry, expunged []uint32) {
+	if b.auditPath == "" || len(expunged) == 0 {
+		return
+	}
+
+	f, err := os.OpenFile(b.auditPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		log.Println("Opening the audit log failed:", err)
+		return
+	}
+	defer f.Close()
+
+	now := now(b)
+	enc := json.NewEncoder(f)
+	for _, uid := range expunged {
+		entry, ok := entries[uid]
+		if !ok {
+			entry = auditEntry{UID: uid}
+		}
+		entry.Time = now
+
+		if err := enc.Encode(entry); err != nil {
+			log.Println("Writing the audit log failed:", err)
+			return
+		}
+	}
+
+	if err := f.Sync(); err != nil {
+		log.Println("Syncing the audit log failed:", err)
+	}
+}
@@ -0,0 +1,289 @@
+package inbox
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-sasl"
+)
+
+// ErrLoginDisabled is returned when the server doesn't allow logging in on the current connection.
+var ErrLoginDisabled = errors.New("inbox: server advertises LOGINDISABLED on this connection, enable TLS or STARTTLS")
+
+// AuthMechanism is a SASL mechanism used to authenticate instead of the LOGIN command.
+type AuthMechanism string
+
+const (
+	AuthPlain   AuthMechanism = sasl.Plain
+	AuthLogin   AuthMechanism = sasl.Login
+	AuthCRAMMD5 AuthMechanism = "CRAM-MD5"
+	// AuthOAuthBearer logs in with an OAuth 2.0 access token (RFC 7628), passed as the password of Credentials.
+	AuthOAuthBearer AuthMechanism = sasl.OAuthBearer
+)
+
+// WithAuthMechanism authenticates with the given SASL mechanism. Without it, the LOGIN command is used.
+func WithAuthMechanism(mech AuthMechanism) Option {
+	return func(i *Inbox) {
+		i.auth = mech
+	}
+}
+
+// login authenticates the connection with the given credentials.
+// Servers advertise LOGINDISABLED until the connection is secured, logging in anyway only yields a misleading
+// authentication error.
+func login(c *client.Client, cred *Credentials, mech AuthMechanism) error {
+	if mech != "" {
+		return authenticate(c, cred, mech)
+	}
+
+	disabled, err := c.Support("LOGINDISABLED")
+	if err != nil {
+		return err
+	}
+
+	if disabled {
+		return ErrLoginDisabled
+	}
+
+	if err := c.Login(cred.Username, cred.Password); err != nil {
+		return loginError(err, cred)
+	}
+
+	return nil
+}
+
+// authenticate runs AUTHENTICATE with the given mechanism, if the server advertises it.
+func authenticate(c *client.Client, cred *Credentials, mech AuthMechanism) error {
+	ok, err := c.SupportAuth(string(mech))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrCapabilityMissing{Capability: "AUTH=" + string(mech)}
+	}
+
+	saslClient, err := newSASLClient(cred, mech)
+	if err != nil {
+		return err
+	}
+
+	if err := c.Authenticate(saslClient); err != nil {
+		return loginError(err, cred)
+	}
+
+	return nil
+}
+
+// newSASLClient returns the client for the mechanism. go-sasl has no CRAM-MD5, so it is implemented here.
+func newSASLClient(cred *Credentials, mech AuthMechanism) (sasl.Client, error) {
+	switch mech {
+	case AuthPlain:
+		return sasl.NewPlainClient("", cred.Username, cred.Password), nil
+	case AuthLogin:
+		return sasl.NewLoginClient(cred.Username, cred.Password), nil
+	case AuthCRAMMD5:
+		return &cramMD5Client{username: cred.Username, password: cred.Password}, nil
+	case AuthOAuthBearer:
+		return sasl.NewOAuthBearerClient(&sasl.OAuthBearerOptions{Username: cred.Username, Token: cred.Password}), nil
+	default:
+		return nil, fmt.Errorf("inbox: unsupported auth mechanism %q", mech)
+	}
+}
+
+// cramMD5Client implements CRAM-MD5 (RFC 2195).
+type cramMD5Client struct {
+	username string
+	password string
+}
+
+func (c *cramMD5Client) Start() (string, []byte, error) {
+	return string(AuthCRAMMD5), nil, nil
+}
+
+func (c *cramMD5Client) Next(challenge []byte) ([]byte, error) {
+	mac := hmac.New(md5.New, []byte(c.password))
+	mac.Write(challenge)
+	return []byte(c.username + " " + hex.EncodeToString(mac.Sum(nil))), nil
+}
+
+// ErrTooManyConnections is returned when the server refuses the login because the account already has too many
+// open connections, e.g. from a phone. Text is the server's own wording.
+type ErrTooManyConnections struct {
+	Text string
+}
+
+func (e ErrTooManyConnections) Error() string {
+	return "inbox: too many simultaneous connections: " + e.Text
+}
+
+// tooManyConnectionsTexts are parts of the responses providers refuse a login with when the connection limit is hit.
+var tooManyConnectionsTexts = []string{
+	"maximum number of connections",
+	"too many connections",
+	"too many simultaneous",
+	"connection limit",
+	"max connections",
+}
+
+// loginError returns the error of a refused login with the credentials removed, as some servers echo the username.
+func loginError(err error, cred *Credentials) error {
+	scrubbed := errors.New(redact(err.Error(), cred))
+	if tooMany := asTooManyConnections(scrubbed); tooMany != scrubbed {
+		return tooMany
+	}
+
+	return fmt.Errorf("%w: %v", ErrAuthentication, scrubbed)
+}
+
+// redactedText replaces credentials in logs and errors.
+const redactedText = "[redacted]"
+
+// redact replaces the username, case-insensitively, and the password in s.
+func redact(s string, cred *Credentials) string {
+	for _, secret := range []string{cred.Username, cred.Password} {
+		if secret != "" {
+			s = replaceFold(s, secret, redactedText)
+		}
+	}
+
+	return s
+}
+
+// replaceFold replaces all case-insensitive occurrences of old in s.
+func replaceFold(s, old, new string) string {
+	lower, lowerOld := strings.ToLower(s), strings.ToLower(old)
+	if len(lower) != len(s) || len(lowerOld) != len(old) {
+		// Lowercasing changed the byte offsets, only exact matches can be replaced.
+		return strings.ReplaceAll(s, old, new)
+	}
+
+	var sb strings.Builder
+	for {
+		i := strings.Index(lower, lowerOld)
+		if i < 0 {
+			break
+		}
+
+		sb.WriteString(s[:i] + new)
+		s, lower = s[i+len(old):], lower[i+len(old):]
+	}
+	sb.WriteString(s)
+
+	return sb.String()
+}
+
+// asTooManyConnections returns ErrTooManyConnections if err is a refused login due to the connection limit.
+func asTooManyConnections(err error) error {
+	text := strings.ToLower(err.Error())
+	for _, t := range tooManyConnectionsTexts {
+		if strings.Contains(text, t) {
+			return ErrTooManyConnections{Text: err.Error()}
+		}
+	}
+
+	return err
+}
+
+// WithLoginRetry retries a login refused with ErrTooManyConnections up to attempts times. The first retry waits
+// delay, every further one twice as long as the previous.
+func WithLoginRetry(attempts int, delay time.Duration) Option {
+	return func(i *Inbox) {
+		i.loginAttempts = attempts
+		i.loginDelay = delay
+	}
+}
+
+// WithTLSConfig connects with the given TLS configuration, e.g. to trust the certificate of a self-hosted server.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(i *Inbox) {
+		i.tlsConfig = cfg
+	}
+}
+
+// connect dials the provider and logs in with mech, retrying as configured with WithLoginRetry.
+func connect(provider ImapProvider, cred *Credentials, mech AuthMechanism, b *Inbox) (*client.Client, error) {
+	delay := b.loginDelay
+	for attempt := 0; ; attempt++ {
+		c, err := client.DialWithDialerTLS(countingDialer{usage: &b.usage}, string(provider), b.tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		err = login(c, cred, mech)
+		if err == nil {
+			return c, nil
+		}
+		err = asAppPasswordRequired(provider, err)
+		c.Logout()
+
+		var tooMany ErrTooManyConnections
+		if !errors.As(err, &tooMany) || attempt >= b.loginAttempts {
+			return nil, err
+		}
+
+		log.Println("Login refused:", tooMany.Text, "- retrying in", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// Reauthenticate replaces the connection by a new one logged in with cred, e.g. after an app password was rotated.
+// The previously selected folder is selected again. If the new login fails, the Inbox is closed and further
+// calls return ErrClosed. It may be called while other operations run: it waits until they finished, and
+// operations started meanwhile wait for the re-login, so no operation sees the connection change. Calling it from
+// a callback of a running operation, like the decide func of Triage, never returns.
+func (b *Inbox) Reauthenticate(cred *Credentials) error {
+	b.mu.Lock()
+	mech := b.auth
+	b.mu.Unlock()
+
+	return reauthenticate(b, cred, mech)
+}
+
+// ReauthenticateOAuth is like Reauthenticate, but logs in with OAUTHBEARER (RFC 7628) and the new access token.
+// Later connections, e.g. of WithAutoReconnect, use the token as well.
+func (b *Inbox) ReauthenticateOAuth(username, token string) error {
+	return reauthenticate(b, &Credentials{Username: username, Password: token}, AuthOAuthBearer)
+}
+
+// reauthenticate swaps the connection for one logged in with cred and mech once no operation runs.
+func reauthenticate(b *Inbox, cred *Credentials, mech AuthMechanism) error {
+	b.ops.lock()
+	defer b.ops.unlock()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed.Load() {
+		return ErrClosed
+	}
+
+	selected := b.client.Mailbox()
+	if err := b.client.Terminate(); err != nil {
+		log.Println("Closing the connection before re-login failed:", err)
+	}
+
+	c, err := connect(b.provider, cred, mech, b)
+	if err != nil {
+		b.closed.Store(true)
+		return err
+	}
+	b.client, b.cred, b.auth, b.bye, b.reconnect = c, cred, mech, watchBye(c), false
+
+	// A failed select leaves the new connection without selection, the next operation selects its folder anyway.
+	if selected != nil {
+		if _, err := c.Select(selected.Name, selected.ReadOnly); err != nil {
+			return fmt.Errorf("inbox: reselecting %s after re-login: %w", selected.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package inbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/server"
+	"github.com/emersion/go-sasl"
+)
+
+// echoBackend refuses every login with a response echoing the credentials, like some servers do.
+type echoBackend struct {
+	backend.Backend
+}
+
+func (echoBackend) Login(_ *imap.ConnInfo, username, password string) (backend.User, error) {
+	return nil, errors.New("login of " + strings.ToUpper(username) + " with " + password + " refused")
+}
+
+func TestLoginErrorRedactsCredentials(t *testing.T) {
+	s := newTestServer(t, func(be backend.Backend) backend.Backend { return echoBackend{be} })
+
+	for _, mech := range []AuthMechanism{"", AuthPlain} {
+		cred := &Credentials{Username: "alice@example.org", Password: "s3cret-pw"}
+		_, err := New(ImapProvider(s.addr), cred, WithTLSConfig(s.client), WithAuthMechanism(mech))
+		if err == nil {
+			t.Fatalf("login with %q succeeded, want an error", mech)
+		}
+		if !errors.Is(err, ErrAuthentication) {
+			t.Errorf("login with %q: error %v is no ErrAuthentication", mech, err)
+		}
+
+		text := strings.ToLower(err.Error())
+		for _, secret := range []string{cred.Username, cred.Password} {
+			if strings.Contains(text, strings.ToLower(secret)) {
+				t.Errorf("login with %q: error %q contains %q", mech, err, secret)
+			}
+		}
+		if !strings.Contains(err.Error(), redactedText) {
+			t.Errorf("login with %q: error %q doesn't carry the server's redacted text", mech, err)
+		}
+	}
+}
+
+// enableOAuthBearer lets the server accept OAUTHBEARER logins of the test user with token.
+func enableOAuthBearer(s *testServer, token string) {
+	s.srv.EnableAuth(sasl.OAuthBearer, func(conn server.Conn) sasl.Server {
+		return sasl.NewOAuthBearerServer(func(opts sasl.OAuthBearerOptions) *sasl.OAuthBearerError {
+			if opts.Username != "username" || opts.Token != token {
+				return &sasl.OAuthBearerError{Status: "invalid_token"}
+			}
+
+			ctx := conn.Context()
+			ctx.State = imap.AuthenticatedState
+			ctx.User = s.user
+			return nil
+		})
+	})
+}
+
+func TestReauthenticateKeepsSelection(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	b := s.dial(t)
+	if _, err := selectFolder(b, "Archive"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := b.Reauthenticate(&Credentials{Username: "username", Password: "password"}); err != nil {
+		t.Fatal(err)
+	}
+	if mbox := currentClient(b).Mailbox(); mbox == nil || mbox.Name != "Archive" {
+		t.Errorf("selected after Reauthenticate = %v, want Archive", mbox)
+	}
+
+	uids, err := b.Find("Archive", criteria.All())
+	if err != nil || len(uids) != 1 {
+		t.Errorf("Find after Reauthenticate = %v, %v, want one message", uids, err)
+	}
+}
+
+func TestReauthenticateOAuth(t *testing.T) {
+	s := newConfiguredServer(t, func(s *testServer) { enableOAuthBearer(s, "access-token") })
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	b := s.dial(t)
+	s.log.Reset()
+
+	if err := b.ReauthenticateOAuth("username", "access-token"); err != nil {
+		t.Fatal(err)
+	}
+	if !s.sent("AUTHENTICATE") {
+		t.Errorf("ReauthenticateOAuth sent %v, want AUTHENTICATE", s.commands())
+	}
+	if _, err := b.Find("Archive", criteria.All()); err != nil {
+		t.Errorf("Find after ReauthenticateOAuth: %v", err)
+	}
+
+	err := b.ReauthenticateOAuth("username", "expired-token")
+	if err == nil {
+		t.Fatal("ReauthenticateOAuth with a refused token succeeded")
+	}
+	if strings.Contains(err.Error(), "expired-token") {
+		t.Errorf("error %q contains the token", err)
+	}
+	if _, err := b.Find("Archive", criteria.All()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Find after a failed re-login = %v, want ErrClosed", err)
+	}
+}
+
+func TestReauthenticateDuringOperations(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	b := s.dial(t)
+
+	errs := make(chan error, 20)
+	go func() {
+		defer close(errs)
+		for i := 0; i < 20; i++ {
+			if _, err := b.Find("Archive", criteria.All()); err != nil {
+				errs <- err
+			}
+		}
+	}()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Reauthenticate(&Credentials{Username: "username", Password: "password"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for err := range errs {
+		t.Errorf("Find during Reauthenticate: %v", err)
+	}
+
+	if _, err := b.Find("Archive", criteria.All()); err != nil {
+		t.Errorf("Find after Reauthenticate: %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package inbox
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+)
+
+// Blocklist is a persistent list of sender addresses and domains to delete mail from.
+// It is stored as a plain text file with one entry per line. Everything after a "#" is a comment.
+// Entries without local part ("@example.com" or "example.com") match the whole domain.
+type Blocklist struct {
+	path    string
+	entries []string
+}
+
+// Load reads the blocklist from path. A missing file results in an empty blocklist.
+// The path is remembered for Save.
+func (bl *Blocklist) Load(path string) error {
+	bl.path = path
+	bl.entries = nil
+
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		bl.Add(line)
+	}
+
+	return scanner.Err()
+}
+
+// Save writes the blocklist back to the path it was loaded from.
+func (bl *Blocklist) Save() error {
+	if bl.path == "" {
+		return errors.New("inbox: blocklist has no path, call Load first")
+	}
+
+	var sb strings.Builder
+	for _, entry := range bl.entries {
+		fmt.Fprintln(&sb, entry)
+	}
+
+	return os.WriteFile(bl.path, []byte(sb.String()), 0o600)
+}
+
+// Add adds the given entries. Empty and already present entries are ignored.
+func (bl *Blocklist) Add(addrs ...string) {
+	for _, addr := range addrs {
+		addr = strings.ToLower(strings.TrimSpace(addr))
+		if addr == "" || bl.contains(addr) {
+			continue
+		}
+
+		bl.entries = append(bl.entries, addr)
+	}
+}
+
+// Remove removes the given entries.
+func (bl *Blocklist) Remove(addrs ...string) {
+	for _, addr := range addrs {
+		addr = strings.ToLower(strings.TrimSpace(addr))
+		for i, entry := range bl.entries {
+			if entry == addr {
+				bl.entries = append(bl.entries[:i], bl.entries[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Entries returns all entries of the blocklist.
+func (bl *Blocklist) Entries() []string {
+	return append([]string(nil), bl.entries...)
+}
+
+func (bl *Blocklist) contains(addr string) bool {
+	for _, entry := range bl.entries {
+		if entry == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DeleteFromBlocklist deletes all messages in the folder sent from one of the blocklist entries.
+// Entries which matched no message are listed in the result's Unmatched field, so stale ones can be pruned.
+func (b *Inbox) DeleteFromBlocklist(expunge bool, folder Folder, bl *Blocklist) (DeleteResult, error) {
+	defer operation(b)()
+	res, err := deleteMessagesInFolderFromAddress(b, expunge, folder, bl.Entries())
+	if err != nil {
+		return res, err
+	}
+
+	if len(res.Unmatched) > 0 {
+		log.Println("Blocklist entries without matches:", strings.Join(res.Unmatched, ", "))
+	}
+
+	return res, nil
+}
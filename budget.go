@@ -0,0 +1,84 @@
+package inbox
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync/atomic"
+)
+
+// ErrBudgetExhausted is returned by multi-folder runs stopped by the budget of WithBudget. Together with
+// WithResumeState, the run can be continued with a new budget, e.g. the next day.
+var ErrBudgetExhausted = errors.New("inbox: command budget exhausted")
+
+// Budget is a number of IMAP commands and bytes transferred. Zero values are unlimited.
+type Budget struct {
+	Commands int64
+	Bytes    int64
+}
+
+// WithBudget stops operations before they exceed the ceiling of commands sent and bytes transferred on the
+// connection, like providers' daily IMAP limits. Like with SetDeadline, no further batches are fetched and no
+// further folders are started once it is used up, and the results are marked as Truncated.
+// BudgetUsed reports the consumption.
+func WithBudget(ceiling Budget) Option {
+	return func(i *Inbox) {
+		i.budget = ceiling
+	}
+}
+
+// BudgetUsed returns the commands sent and bytes transferred on the connection so far, across reconnects.
+func (b *Inbox) BudgetUsed() Budget {
+	return Budget{Commands: b.usage.commands.Load(), Bytes: b.usage.bytes.Load()}
+}
+
+// usage counts the commands and bytes of an Inbox.
+type usage struct {
+	commands atomic.Int64
+	bytes    atomic.Int64
+}
+
+// budgetExhausted reports whether the budget of WithBudget is used up.
+func budgetExhausted(b *Inbox) bool {
+	used := b.BudgetUsed()
+	return b.budget.Commands > 0 && used.Commands >= b.budget.Commands ||
+		b.budget.Bytes > 0 && used.Bytes >= b.budget.Bytes
+}
+
+// countingDialer dials connections counting their traffic.
+type countingDialer struct {
+	usage *usage
+}
+
+func (d countingDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := new(net.Dialer).Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &countingConn{Conn: conn, usage: d.usage}, nil
+}
+
+// countingConn counts the bytes read and written.
+type countingConn struct {
+	net.Conn
+	usage *usage
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.usage.bytes.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.usage.bytes.Add(int64(n))
+	return n, err
+}
+
+// logBudget logs the consumption once the budget is used up.
+func logBudget(b *Inbox) {
+	used := b.BudgetUsed()
+	log.Println("Budget used up:", used.Commands, "commands,", used.Bytes, "bytes")
+}
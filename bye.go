@@ -0,0 +1,82 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+)
+
+// WithAutoReconnect makes the Inbox log in again before the next command after the server closed the connection,
+// e.g. with "* BYE Autologout" after inactivity. The command running when the connection was lost still fails
+// with ErrConnectionLost, but multi-folder and multi-rule runs continue with the next unit.
+func WithAutoReconnect(enabled bool) Option {
+	return func(i *Inbox) {
+		i.autoReconnect = enabled
+	}
+}
+
+// byeWatch records the BYE text the server sent on a connection.
+type byeWatch struct {
+	text atomic.Pointer[string]
+}
+
+// watchBye consumes the unilateral updates of c and records a BYE in the returned byeWatch. The client
+// closes the connection itself after a BYE. The goroutine ends when the connection is closed.
+func watchBye(c *client.Client) *byeWatch {
+	watch := new(byeWatch)
+	updates := make(chan client.Update, 16)
+	c.Updates = updates
+
+	go func() {
+		for {
+			select {
+			case update := <-updates:
+				status, ok := update.(*client.StatusUpdate)
+				if ok && status.Status.Type == imap.StatusRespBye {
+					text := status.Status.Info
+					watch.text.Store(&text)
+					log.Println("Server closed the connection:", text)
+				}
+			case <-c.LoggedOut():
+				return
+			}
+		}
+	}()
+
+	return watch
+}
+
+// loggedOut reports whether the connection of c is closed, by a BYE, a network error or a logout.
+func loggedOut(c *client.Client) bool {
+	select {
+	case <-c.LoggedOut():
+		return true
+	default:
+		return false
+	}
+}
+
+// connectionLost returns ErrConnectionLost with the BYE text of the server, or err if the connection is still
+// usable or the Inbox was logged out on purpose. err may be nil for the check before a command.
+func connectionLost(b *Inbox, err error) error {
+	if b.closed.Load() || !loggedOut(currentClient(b)) {
+		return err
+	}
+
+	b.mu.Lock()
+	bye := b.bye
+	b.mu.Unlock()
+	if bye != nil {
+		if text := bye.text.Load(); text != nil {
+			return fmt.Errorf("%w: server said BYE %q", ErrConnectionLost, *text)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionLost, err)
+	}
+
+	return ErrConnectionLost
+}
@@ -0,0 +1,239 @@
+package inbox
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/emersion/go-imap"
+)
+
+// WithCache keeps envelopes, sizes and flags of fetched messages in the gob file at path. Later fetches of
+// these items only download the envelopes of messages not in the cache yet and refresh the flags, which may
+// change at any time. A folder's cache is dropped automatically when its UIDVALIDITY changes. The file is written
+// when the Inbox logs out.
+func WithCache(path string) Option {
+	return func(i *Inbox) {
+		i.cache = &envelopeCache{path: path}
+	}
+}
+
+// cacheableItems are the fetch items the envelope cache can answer.
+var cacheableItems = map[imap.FetchItem]bool{
+	imap.FetchUid:        true,
+	imap.FetchEnvelope:   true,
+	imap.FetchRFC822Size: true,
+	imap.FetchFlags:      true,
+}
+
+// cachedMessage holds the cached items of a message.
+type cachedMessage struct {
+	Envelope *imap.Envelope
+	Size     uint32
+	Flags    []string
+}
+
+// cachedFolder holds the cached messages of a folder by UID.
+type cachedFolder struct {
+	UIDValidity uint32
+	Messages    map[uint32]cachedMessage
+}
+
+// envelopeCache is an on-disk cache of message envelopes, keyed by folder, UIDVALIDITY and UID.
+type envelopeCache struct {
+	path    string
+	mu      sync.Mutex
+	folders map[string]*cachedFolder
+	// dirty is set when the cache changed since the file was written.
+	dirty bool
+}
+
+// load reads the cache file on first use. A missing file is an empty cache.
+func (c *envelopeCache) load() error {
+	if c.folders != nil {
+		return nil
+	}
+
+	folders := make(map[string]*cachedFolder)
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		c.folders = folders
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&folders); err != nil {
+		return err
+	}
+	c.folders = folders
+
+	return nil
+}
+
+func (c *envelopeCache) save() error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c.folders); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.path, buf.Bytes())
+}
+
+// folder returns the cache of the folder, emptied if the UIDVALIDITY differs from the cached one.
+func (c *envelopeCache) folder(name string, uidValidity uint32) *cachedFolder {
+	f, ok := c.folders[name]
+	if !ok || f.UIDValidity != uidValidity {
+		f = &cachedFolder{UIDValidity: uidValidity, Messages: make(map[uint32]cachedMessage)}
+		c.folders[name] = f
+	}
+
+	return f
+}
+
+// uidFetch fetches the items of the messages in uidSet from the selected folder, like UidFetch. The envelope
+// cache is consulted if set up and it holds all requested items.
+func uidFetch(b *Inbox, uidSet *imap.SeqSet, items []imap.FetchItem, messages chan *imap.Message) error {
+	if b.cache == nil || !cacheable(items) {
+		return conn(b).UidFetch(uidSet, items, messages)
+	}
+
+	return b.cache.fetch(b, uidSet, messages)
+}
+
+// cacheable reports whether the envelope cache holds all items.
+func cacheable(items []imap.FetchItem) bool {
+	for _, item := range items {
+		if !cacheableItems[item] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// fetch sends the messages in uidSet with all cacheable items. Flags are always fetched, envelopes and sizes
+// only for messages missing from the cache. messages is closed when done. The lock is only held while the cache
+// is read or updated, never during a FETCH.
+func (c *envelopeCache) fetch(b *Inbox, uidSet *imap.SeqSet, messages chan *imap.Message) error {
+	defer close(messages)
+
+	mbox := currentClient(b).Mailbox()
+	if mbox == nil {
+		return errors.New("inbox: no folder selected")
+	}
+
+	var current []*imap.Message
+	err := fetchEach(b, uidSet, []imap.FetchItem{imap.FetchFlags}, func(msg *imap.Message) {
+		current = append(current, msg)
+	})
+	if err != nil {
+		return err
+	}
+
+	missing, err := c.missing(mbox, current)
+	if err != nil {
+		return err
+	}
+
+	fetched := make(map[uint32]cachedMessage)
+	if !missing.Empty() {
+		items := []imap.FetchItem{imap.FetchEnvelope, imap.FetchRFC822Size}
+		err := fetchEach(b, missing, items, func(msg *imap.Message) {
+			fetched[msg.Uid] = cachedMessage{Envelope: msg.Envelope, Size: msg.Size}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range c.update(mbox, current, fetched, isFullRange(uidSet)) {
+		messages <- msg
+	}
+
+	return nil
+}
+
+// missing returns the UIDs of the messages whose envelope is not cached yet.
+func (c *envelopeCache) missing(mbox *imap.MailboxStatus, current []*imap.Message) (*imap.SeqSet, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+
+	folder := c.folder(mbox.Name, mbox.UidValidity)
+	missing := new(imap.SeqSet)
+	for _, msg := range current {
+		if _, ok := folder.Messages[msg.Uid]; !ok {
+			missing.AddNum(msg.Uid)
+		}
+	}
+
+	return missing, nil
+}
+
+// update stores the fetched envelopes and the current flags and returns the messages with all cached items.
+// With all set, the fetch covered the whole folder and messages not seen are dropped.
+func (c *envelopeCache) update(mbox *imap.MailboxStatus, current []*imap.Message, fetched map[uint32]cachedMessage, all bool) []*imap.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	folder := c.folder(mbox.Name, mbox.UidValidity)
+	for uid, cached := range fetched {
+		folder.Messages[uid] = cached
+	}
+
+	out := make([]*imap.Message, 0, len(current))
+	seen := make(map[uint32]bool, len(current))
+	for _, msg := range current {
+		cached := folder.Messages[msg.Uid]
+		cached.Flags = msg.Flags
+		folder.Messages[msg.Uid] = cached
+		seen[msg.Uid] = true
+
+		m := imap.NewMessage(msg.SeqNum, []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope, imap.FetchRFC822Size, imap.FetchFlags})
+		m.Uid = msg.Uid
+		m.Envelope = cached.Envelope
+		m.Size = cached.Size
+		m.Flags = cached.Flags
+		out = append(out, m)
+	}
+
+	if all {
+		// Only a fetch of the whole folder shows which messages are gone.
+		for uid := range folder.Messages {
+			if !seen[uid] {
+				delete(folder.Messages, uid)
+			}
+		}
+	}
+	c.dirty = true
+
+	return out
+}
+
+// flush writes the cache file if anything changed since it was last written.
+func (c *envelopeCache) flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	if err := c.save(); err != nil {
+		return err
+	}
+	c.dirty = false
+
+	return nil
+}
+
+// isFullRange reports whether the set is 1:*.
+func isFullRange(set *imap.SeqSet) bool {
+	return len(set.Set) == 1 && set.Set[0].Start == 1 && set.Set[0].Stop == 0
+}
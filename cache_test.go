@@ -0,0 +1,120 @@
+package inbox
+
+import (
+	"bytes"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+)
+
+// validityBackend reports the UIDVALIDITY held in validity for every mailbox.
+type validityBackend struct {
+	backend.Backend
+	validity *atomic.Uint32
+}
+
+func (be validityBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := be.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return validityUser{User: u, validity: be.validity}, nil
+}
+
+type validityUser struct {
+	backend.User
+	validity *atomic.Uint32
+}
+
+func (u validityUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return validityMailbox{Mailbox: mbox, validity: u.validity}, nil
+}
+
+type validityMailbox struct {
+	backend.Mailbox
+	validity *atomic.Uint32
+}
+
+func (m validityMailbox) Status(items []imap.StatusItem) (*imap.MailboxStatus, error) {
+	status, err := m.Mailbox.Status(items)
+	if err != nil {
+		return nil, err
+	}
+	status.UidValidity = m.validity.Load()
+
+	return status, nil
+}
+
+// cachedSubject fetches the envelope of the only message in folder through the cache.
+func cachedSubject(t *testing.T, b *Inbox, folder Folder) string {
+	t.Helper()
+
+	if _, err := examineFolder(b, folder); err != nil {
+		t.Fatal(err)
+	}
+
+	uidSet, _ := imap.ParseSeqSet("1:*")
+	messages := make(chan *imap.Message, 10)
+	if err := uidFetch(b, uidSet, []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope}, messages); err != nil {
+		t.Fatal(err)
+	}
+
+	var subjects []string
+	for msg := range messages {
+		subjects = append(subjects, msg.Envelope.Subject)
+	}
+	if len(subjects) != 1 {
+		t.Fatalf("fetched %d messages, want 1", len(subjects))
+	}
+
+	return subjects[0]
+}
+
+func TestCacheUIDValidityChange(t *testing.T) {
+	validity := new(atomic.Uint32)
+	validity.Store(1)
+	s := newTestServer(t, func(be backend.Backend) backend.Backend {
+		return validityBackend{Backend: be, validity: validity}
+	})
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	path := t.TempDir() + "/cache"
+
+	b := s.dial(t, WithCache(path))
+	if got := cachedSubject(t, b, "Archive"); got != "first" {
+		t.Fatalf("subject = %q, want first", got)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Error("cache file written before logout")
+	}
+	if err := b.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("cache file not written on logout: %v", err)
+	}
+
+	// The server reuses the UID for another message, which is only noticed through the UIDVALIDITY.
+	msg := s.mailbox(t, "Archive").Messages[0]
+	msg.Body = bytes.Replace(msg.Body, []byte("Subject: first"), []byte("Subject: replaced"), 1)
+
+	b = s.dial(t, WithCache(path))
+	if got := cachedSubject(t, b, "Archive"); got != "first" {
+		t.Errorf("subject with unchanged UIDVALIDITY = %q, want the cached first", got)
+	}
+
+	validity.Store(2)
+	b = s.dial(t, WithCache(path))
+	if got := cachedSubject(t, b, "Archive"); got != "replaced" {
+		t.Errorf("subject after UIDVALIDITY change = %q, want replaced", got)
+	}
+}
@@ -0,0 +1,88 @@
+package inbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// drainGrace is how long the rest of a cancelled fetch is drained before the connection is abandoned.
+var drainGrace = 5 * time.Second
+
+// withContext makes ctx the context of the running operation, so fetches stop when it is cancelled.
+// The returned function restores the previous context.
+func withContext(b *Inbox, ctx context.Context) func() {
+	prev := b.ctx
+	b.ctx = ctx
+	return func() { b.ctx = prev }
+}
+
+// consume calls fn for every fetched message until messages is closed and returns the error of the fetch.
+// When the context of the running operation is cancelled, fn isn't called anymore and the fetch is cleaned up
+// with drainFetch.
+func consume(b *Inbox, messages chan *imap.Message, errChan chan error, fn func(*imap.Message)) error {
+	var done <-chan struct{}
+	if b.ctx != nil {
+		done = b.ctx.Done()
+	}
+
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				return connectionLost(b, <-errChan)
+			}
+			fn(msg)
+		case <-done:
+			return drainFetch(b, messages, errChan)
+		}
+	}
+}
+
+// drainFetch discards the remaining messages of a cancelled fetch, as go-imap blocks the connection until they are
+// consumed, and returns the context's error. If the fetch doesn't complete within drainGrace, the connection is
+// abandoned and the next operation reconnects, see reconnectIfNeeded. The fetch goroutine ends either way.
+func drainFetch(b *Inbox, messages chan *imap.Message, errChan chan error) error {
+	go func() {
+		for range messages {
+		}
+	}()
+
+	select {
+	case <-errChan:
+	case <-time.After(drainGrace):
+		log.Println("Abandoning the connection, the cancelled fetch didn't complete in", drainGrace)
+		b.mu.Lock()
+		b.reconnect = true
+		b.mu.Unlock()
+		if err := currentClient(b).Terminate(); err != nil {
+			log.Println("Closing the connection failed:", err)
+		}
+	}
+
+	return b.ctx.Err()
+}
+
+// reconnectIfNeeded replaces a connection abandoned by drainFetch, or lost with WithAutoReconnect, with a new one.
+func reconnectIfNeeded(b *Inbox) error {
+	b.mu.Lock()
+	reconnect, cred, mech := b.reconnect, b.cred, b.auth
+	b.mu.Unlock()
+	if !reconnect {
+		return nil
+	}
+
+	c, err := connect(b.provider, cred, mech, b)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.client, b.bye, b.reconnect = c, watchBye(c), false
+	b.mu.Unlock()
+	invalidateSelected(b)
+	log.Println("Reconnected to", b.provider)
+	return nil
+}
@@ -0,0 +1,148 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+)
+
+// stallBackend stops every FETCH after the first message while stall is set, until release is closed.
+type stallBackend struct {
+	backend.Backend
+	stall   *atomic.Bool
+	release chan struct{}
+}
+
+func (be stallBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := be.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return stallUser{User: u, be: be}, nil
+}
+
+type stallUser struct {
+	backend.User
+	be stallBackend
+}
+
+func (u stallUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return stallMailbox{Mailbox: mbox, be: u.be}, nil
+}
+
+type stallMailbox struct {
+	backend.Mailbox
+	be stallBackend
+}
+
+func (m stallMailbox) ListMessages(uid bool, seqSet *imap.SeqSet, items []imap.FetchItem, ch chan<- *imap.Message) error {
+	if !m.be.stall.Load() {
+		return m.Mailbox.ListMessages(uid, seqSet, items, ch)
+	}
+
+	defer close(ch)
+	all := make(chan *imap.Message, 1000)
+	if err := m.Mailbox.ListMessages(uid, seqSet, items, all); err != nil {
+		return err
+	}
+	if msg, ok := <-all; ok {
+		ch <- msg
+	}
+	<-m.be.release
+
+	return nil
+}
+
+// cancelOnFirst runs findMessages over the whole selected folder with a context cancelled by the first message.
+func cancelOnFirst(b *Inbox) (int, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer withContext(b, ctx)()
+
+	seen := 0
+	err := findMessages(b, criteria.All(), nil, func(*imap.Message) bool {
+		seen++
+		cancel()
+		return true
+	})
+
+	return seen, err
+}
+
+func TestCancelledFetchDrains(t *testing.T) {
+	s := newTestServer(t)
+	for i := 0; i < 200; i++ {
+		s.addMessage(t, "Archive", "a@example.com", fmt.Sprint("message ", i), time.Now())
+	}
+	b := s.dial(t)
+	if _, err := selectFolder(b, "Archive"); err != nil {
+		t.Fatal(err)
+	}
+
+	seen, err := cancelOnFirst(b)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled fetch returned %v, want context.Canceled", err)
+	}
+	if seen == 200 {
+		t.Error("the cancelled fetch handed out all messages")
+	}
+	if b.reconnect {
+		t.Error("a drained fetch abandoned the connection")
+	}
+
+	uids, err := b.Find("Archive", criteria.All())
+	if err != nil {
+		t.Fatalf("Find after the cancelled fetch: %v", err)
+	}
+	if len(uids) != 200 {
+		t.Errorf("Find after the cancelled fetch returned %d messages, want 200", len(uids))
+	}
+}
+
+func TestCancelledFetchReconnects(t *testing.T) {
+	defer func(grace time.Duration) { drainGrace = grace }(drainGrace)
+	drainGrace = 100 * time.Millisecond
+
+	stall := new(atomic.Bool)
+	release := make(chan struct{})
+	s := newTestServer(t, func(be backend.Backend) backend.Backend {
+		return stallBackend{Backend: be, stall: stall, release: release}
+	})
+	t.Cleanup(func() { close(release) })
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	s.addMessage(t, "Archive", "b@example.com", "second", time.Now())
+	b := s.dial(t)
+	if _, err := selectFolder(b, "Archive"); err != nil {
+		t.Fatal(err)
+	}
+
+	stall.Store(true)
+	if _, err := cancelOnFirst(b); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled fetch returned %v, want context.Canceled", err)
+	}
+	if !b.reconnect {
+		t.Fatal("a stalled fetch didn't abandon the connection")
+	}
+	stall.Store(false)
+
+	summaries, err := b.FindSummaries("Archive", criteria.All())
+	if err != nil {
+		t.Fatalf("FindSummaries after the abandoned fetch: %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Errorf("FindSummaries after the abandoned fetch returned %d messages, want 2", len(summaries))
+	}
+}
@@ -0,0 +1,79 @@
+package inbox
+
+import "github.com/Batzi1337/go-imapcleaner/criteria"
+
+// ErrCapabilityMissing is returned when a feature needs a capability the server doesn't advertise.
+type ErrCapabilityMissing struct {
+	Capability string
+}
+
+func (e ErrCapabilityMissing) Error() string {
+	return "inbox: server doesn't support " + e.Capability
+}
+
+// requireCapability returns ErrCapabilityMissing if the server doesn't advertise the capability.
+func requireCapability(b *Inbox, capability string) error {
+	if err := checkOpen(b); err != nil {
+		return err
+	}
+
+	ok, err := currentClient(b).Support(capability)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrCapabilityMissing{Capability: capability}
+	}
+
+	return nil
+}
+
+// requireCriteria returns ErrCapabilityMissing if crit needs a capability the server doesn't advertise.
+func requireCriteria(b *Inbox, crit criteria.Criteria) error {
+	for _, capability := range criteria.Requirements(crit) {
+		if err := requireCapability(b, capability); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Capabilities returns the capabilities the server advertises. The response is cached by the connection and
+// requested again after login, as servers advertise more once authenticated.
+func (b *Inbox) Capabilities() (map[string]bool, error) {
+	defer operation(b)()
+	if err := checkOpen(b); err != nil {
+		return nil, err
+	}
+
+	return currentClient(b).Capability()
+}
+
+// supports reports whether the server advertises the capability, false if that can't be determined.
+func supports(b *Inbox, capability string) bool {
+	ok, err := currentClient(b).Support(capability)
+	return err == nil && ok
+}
+
+// SupportsMove reports whether messages are moved with MOVE. Without it, Move falls back to copy and delete.
+func (b *Inbox) SupportsMove() bool { return supports(b, "MOVE") }
+
+// SupportsUIDPlus reports whether the server supports UIDPLUS, e.g. for UID EXPUNGE.
+func (b *Inbox) SupportsUIDPlus() bool { return supports(b, "UIDPLUS") }
+
+// SupportsQuota reports whether the server supports QUOTA.
+func (b *Inbox) SupportsQuota() bool { return supports(b, "QUOTA") }
+
+// SupportsSpecialUse reports whether the server marks folders with SPECIAL-USE attributes.
+func (b *Inbox) SupportsSpecialUse() bool { return supports(b, "SPECIAL-USE") }
+
+// SupportsIdle reports whether the server supports IDLE.
+func (b *Inbox) SupportsIdle() bool { return supports(b, "IDLE") }
+
+// SupportsCondStore reports whether the server supports CONDSTORE, needed by CleanChangedSince.
+func (b *Inbox) SupportsCondStore() bool { return supports(b, "CONDSTORE") }
+
+// SupportsGmail reports whether the server supports the Gmail extensions, needed by the Gmail label features.
+func (b *Inbox) SupportsGmail() bool { return supports(b, criteria.GmailCapability) }
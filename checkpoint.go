@@ -0,0 +1,127 @@
+package inbox
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+// Checkpoint records how far a folder was scanned for a ruleset.
+type Checkpoint struct {
+	UIDValidity uint32 `json:"uidValidity"`
+	// LastUID is the highest UID examined.
+	LastUID uint32 `json:"lastUid"`
+}
+
+// CheckpointStore persists checkpoints between runs.
+type CheckpointStore interface {
+	// Load returns the checkpoint stored for key, ok is false if there is none.
+	Load(key string) (cp Checkpoint, ok bool, err error)
+	Save(key string, cp Checkpoint) error
+}
+
+// WithCheckpoints stores the progress of DeleteIncremental in store.
+func WithCheckpoints(store CheckpointStore) Option {
+	return func(i *Inbox) {
+		i.checkpoints = store
+	}
+}
+
+// fileCheckpointStore keeps all checkpoints in a single JSON file.
+type fileCheckpointStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileCheckpointStore returns a CheckpointStore keeping all checkpoints in the JSON file at path.
+func NewFileCheckpointStore(path string) CheckpointStore {
+	return &fileCheckpointStore{path: path}
+}
+
+func (s *fileCheckpointStore) read() (map[string]Checkpoint, error) {
+	cps := make(map[string]Checkpoint)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cps, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return cps, json.Unmarshal(data, &cps)
+}
+
+func (s *fileCheckpointStore) Load(key string) (Checkpoint, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cps, err := s.read()
+	if err != nil {
+		return Checkpoint{}, false, err
+	}
+
+	cp, ok := cps[key]
+	return cp, ok, nil
+}
+
+func (s *fileCheckpointStore) Save(key string, cp Checkpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cps, err := s.read()
+	if err != nil {
+		return err
+	}
+	cps[key] = cp
+
+	data, err := json.MarshalIndent(cps, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(s.path, data)
+}
+
+// DeleteIncremental is like Delete, but only examines the messages which arrived since the last run of the same
+// ruleset on the folder, as recorded in the WithCheckpoints store. A changed UIDVALIDITY falls back to a full
+// scan. Time relative criteria like OlderThan always search the whole folder, as old messages start matching
+// without changing. The checkpoint only advances when expunge is set, so a dry run never hides messages.
+func (b *Inbox) DeleteIncremental(expunge bool, folder Folder, ruleset string, crit criteria.Criteria) (DeleteResult, error) {
+	defer operation(b)()
+	if b.checkpoints == nil {
+		return DeleteResult{Folder: folder}, errors.New("inbox: DeleteIncremental needs WithCheckpoints")
+	}
+
+	mbox, err := selectFolder(b, folder)
+	if err != nil {
+		return DeleteResult{Folder: folder}, err
+	}
+
+	key := ruleset + "/" + string(folder)
+	cp, ok, err := b.checkpoints.Load(key)
+	if err != nil {
+		return DeleteResult{Folder: folder}, err
+	}
+
+	scan := crit
+	switch {
+	case !ok:
+	case cp.UIDValidity != mbox.UidValidity:
+		log.Println("UIDVALIDITY of", folder, "changed, scanning all messages")
+	case criteria.IsTimeRelative(crit):
+	default:
+		scan = criteria.And(crit, criteria.UIDsAbove(cp.LastUID))
+	}
+
+	res, err := b.Delete(expunge, folder, scan)
+	if err != nil || !expunge || mbox.UidNext == 0 {
+		return res, err
+	}
+
+	// Messages arriving after the select are above the checkpoint and get examined next time.
+	return res, b.checkpoints.Save(key, Checkpoint{UIDValidity: mbox.UidValidity, LastUID: mbox.UidNext - 1})
+}
@@ -0,0 +1,110 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"sort"
+
+	"github.com/emersion/go-imap"
+)
+
+// FolderAction is what CleanFoldersMatching does with every matching folder.
+type FolderAction int
+
+const (
+	// EmptyOnly expunges all messages, the folder is kept.
+	EmptyOnly FolderAction = iota
+	// EmptyAndDelete expunges all messages and deletes the folder afterwards.
+	EmptyAndDelete
+)
+
+// FolderResult is the outcome of CleanFoldersMatching for a single folder.
+type FolderResult struct {
+	DeleteResult
+	// FolderDeleted is true when the folder itself was deleted.
+	FolderDeleted bool
+}
+
+// CleanFoldersMatching applies action to every selectable folder whose logical name matches the glob pattern,
+// like "Tickets/2021-*". The pattern is matched with path.Match, so "*" doesn't match "/". Patterns matching the
+// INBOX or a special-use folder are refused with ErrConfirmationRequired unless WithConfirmDestructive(true) was
+// given. Children are cleaned before their parents, a parent with remaining children isn't deleted. Neither is a
+// folder which still holds preserved messages, see WithPreserveFlagged. Failing folders carry their error in Err
+// and don't stop the run unless the error is fatal, see isFatal.
+func (b *Inbox) CleanFoldersMatching(pattern string, action FolderAction) ([]FolderResult, error) {
+	defer operation(b)()
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("inbox: invalid folder pattern %q: %w", pattern, err)
+	}
+
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	protected, err := protectedFolders(b)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[string]bool, len(mailboxes))
+	var matching []*imap.MailboxInfo
+	for _, mbox := range mailboxes {
+		remaining[mbox.Name] = true
+		if ok, _ := path.Match(pattern, string(logicalFolder(b, mbox.Name))); !ok || !selectable(mbox) {
+			continue
+		}
+
+		if protected(mbox) && !b.confirm {
+			return nil, fmt.Errorf("%w: %q matches %s, which needs WithConfirmDestructive(true)", ErrConfirmationRequired, pattern, mbox.Name)
+		}
+		matching = append(matching, mbox)
+	}
+
+	sort.SliceStable(matching, func(i, j int) bool { return folderDepth(matching[i]) > folderDepth(matching[j]) })
+	log.Println("Folders matching", pattern+":", len(matching))
+
+	var results []FolderResult
+	var errs []error
+	for _, mbox := range matching {
+		folder := logicalFolder(b, mbox.Name)
+		res, err := deleteAllMessagesInFolder(b, true, folder)
+		res.Folder = folder
+		res.Err = err
+		results = append(results, FolderResult{DeleteResult: res})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				break
+			}
+			continue
+		}
+
+		if action != EmptyAndDelete || hasChildren(mbox, remaining) || skipMutation(b, "delete folder", folder) {
+			continue
+		}
+		if res.Preserved > 0 {
+			// Deleting the folder would destroy the preserved messages.
+			log.Println("Keeping folder", folder, "with", res.Preserved, "preserved messages")
+			continue
+		}
+
+		invalidateSelected(b)
+		if err := conn(b).Delete(mbox.Name); err != nil {
+			results[len(results)-1].Err = err
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				break
+			}
+			continue
+		}
+
+		log.Println("Deleted folder", folder)
+		delete(remaining, mbox.Name)
+		results[len(results)-1].FolderDeleted = true
+	}
+
+	return results, errors.Join(errs...)
+}
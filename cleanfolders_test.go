@@ -0,0 +1,34 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+func TestCleanFoldersMatchingKeepsPreserved(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Tickets/2021-01", "a@example.com", "flagged", time.Now(), imap.FlaggedFlag)
+	s.addMessage(t, "Tickets/2021-01", "a@example.com", "plain", time.Now())
+	s.addMessage(t, "Tickets/2021-02", "a@example.com", "other", time.Now())
+	b := s.dial(t)
+
+	results, err := b.CleanFoldersMatching("Tickets/2021-*", EmptyAndDelete)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := make(map[Folder]bool)
+	for _, res := range results {
+		deleted[res.Folder] = res.FolderDeleted
+	}
+	if deleted["Tickets/2021-01"] || !deleted["Tickets/2021-02"] {
+		t.Errorf("FolderDeleted = %v, want only Tickets/2021-02 deleted", deleted)
+	}
+
+	msgs := s.mailbox(t, "Tickets/2021-01").Messages
+	if len(msgs) != 1 {
+		t.Fatalf("Tickets/2021-01 holds %d messages, want the flagged one", len(msgs))
+	}
+}
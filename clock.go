@@ -0,0 +1,20 @@
+package inbox
+
+import "time"
+
+// SetClock replaces time.Now for everything the Inbox computes relative to now, like the cutoffs of
+// DeleteExpiredInvites, TrimNewsletters and EmptyRecycle, the recycle keywords, AgeHistogram, the deadline of
+// SetDeadline and audit timestamps. Durations like those of metrics and progress are still measured with the real time.
+// Criteria compute their cutoffs when they are created, see criteria.SetClock. nil restores time.Now.
+func (b *Inbox) SetClock(clock func() time.Time) {
+	b.clock = clock
+}
+
+// now returns the current time of the clock set with SetClock.
+func now(b *Inbox) time.Time {
+	if b.clock == nil {
+		return time.Now()
+	}
+
+	return b.clock()
+}
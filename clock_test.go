@@ -0,0 +1,50 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+// The frozen time lies years ahead, so the real clock would find no message old enough.
+var frozen = time.Date(2040, 1, 15, 12, 0, 0, 0, time.UTC)
+
+func TestSetClockCutoff(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, RecycleFolder, "a@example.com", "old", frozen.AddDate(0, 0, -10))
+	recent := s.addMessage(t, RecycleFolder, "b@example.com", "recent", frozen.AddDate(0, 0, -2))
+	b := s.dial(t)
+	b.SetClock(func() time.Time { return frozen })
+
+	res, err := b.EmptyRecycle(7 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matched != 1 || res.Deleted != 1 {
+		t.Errorf("EmptyRecycle matched %d and deleted %d, want 1 each", res.Matched, res.Deleted)
+	}
+
+	msgs := s.mailbox(t, string(RecycleFolder)).Messages
+	if len(msgs) != 1 || msgs[0].Uid != recent {
+		t.Errorf("%d messages left in the recycle folder, want only the recent one", len(msgs))
+	}
+}
+
+func TestCriteriaSetClockCutoff(t *testing.T) {
+	criteria.SetClock(func() time.Time { return frozen })
+	defer criteria.SetClock(nil)
+
+	s := newTestServer(t)
+	old := s.addMessage(t, "Archive", "a@example.com", "old", frozen.AddDate(0, 0, -10))
+	s.addMessage(t, "Archive", "b@example.com", "recent", frozen.AddDate(0, 0, -6))
+	b := s.dial(t)
+
+	uids, err := b.Find("Archive", criteria.OlderThan(7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uids) != 1 || uids[0] != old {
+		t.Errorf("Find(OlderThan(7 days)) = %v, want [%d]", uids, old)
+	}
+}
@@ -0,0 +1,105 @@
+// Command inboxcleaner deletes messages from an IMAP account.
+//
+// Without -expunge it only reports what would be deleted. Expunging needs -confirm=DELETE, a typed confirmation
+// on a terminal, or -yes for unattended runs.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	inbox "github.com/Batzi1337/go-imapcleaner"
+)
+
+// safeWord must be given or typed before messages are removed permanently.
+const safeWord = "DELETE"
+
+var errNotConfirmed = errors.New("inboxcleaner: expunge not confirmed, pass -confirm=" + safeWord + " or -yes")
+
+func main() {
+	server := flag.String("server", string(inbox.GMX), "IMAP server as host:port")
+	user := flag.String("user", "", "account username")
+	folders := flag.String("folders", string(inbox.InboxFolder), "comma separated folders to clean")
+	from := flag.String("from", "", "comma separated sender addresses to delete, all messages when empty")
+	expunge := flag.Bool("expunge", false, "remove messages permanently instead of only reporting them")
+	confirm := flag.String("confirm", "", "set to "+safeWord+" to confirm -expunge without a prompt")
+	yes := flag.Bool("yes", false, "skip the expunge confirmation for automation")
+	flag.Parse()
+
+	if *expunge {
+		if err := confirmExpunge(*confirm, *yes, os.Stdin, os.Stderr); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	cred := &inbox.Credentials{Username: *user, Password: os.Getenv("INBOX_PASSWORD")}
+	ib, err := inbox.New(inbox.ImapProvider(*server), cred, inbox.WithConfirmDestructive(*expunge))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ib.Logout()
+
+	var targets []inbox.Folder
+	for _, f := range splitList(*folders) {
+		targets = append(targets, inbox.Folder(f))
+	}
+
+	if addr := splitList(*from); len(addr) > 0 {
+		_, err = ib.DeleteMessagesInFoldersFromAddress(*expunge, targets, addr...)
+	} else {
+		_, err = ib.DeleteAllMessagesInFolders(*expunge, targets...)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// confirmExpunge checks the destructive intent was confirmed. Without -confirm or -yes, the safe word is prompted
+// for on a terminal. Non-interactive runs fail instead of blocking on a prompt nobody answers.
+func confirmExpunge(confirm string, yes bool, in *os.File, out io.Writer) error {
+	if yes || confirm == safeWord {
+		return nil
+	}
+
+	if confirm != "" {
+		return fmt.Errorf("inboxcleaner: -confirm must be %s, got %q", safeWord, confirm)
+	}
+
+	if !isTerminal(in) {
+		return errNotConfirmed
+	}
+
+	fmt.Fprintf(out, "Messages will be removed permanently. Type %s to continue: ", safeWord)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if strings.TrimSpace(answer) != safeWord {
+		return errNotConfirmed
+	}
+
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,130 @@
+package inbox
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/commands"
+	"github.com/emersion/go-imap/responses"
+)
+
+// statusHighestModSeq is the STATUS item of RFC 7162 holding the highest mod-sequence of a mailbox.
+const statusHighestModSeq imap.StatusItem = "HIGHESTMODSEQ"
+
+// HighestModSeq returns the MODSEQ high-water mark recorded for the folder by the last CleanChangedSince.
+func (b *Inbox) HighestModSeq(folder Folder) uint64 {
+	return b.modSeqs[folder]
+}
+
+// SetHighestModSeq sets the MODSEQ high-water mark for the folder, e.g. restored from a previous run.
+func (b *Inbox) SetHighestModSeq(folder Folder, modSeq uint64) {
+	if b.modSeqs == nil {
+		b.modSeqs = make(map[Folder]uint64)
+	}
+	b.modSeqs[folder] = modSeq
+}
+
+// CleanChangedSince deletes messages from the given addresses, but only looks at messages which changed
+// after modSeq (CONDSTORE CHANGEDSINCE). Afterwards the folder's high-water mark is updated, so a daemon
+// can pass HighestModSeq(folder) on its next cycle. Needs the CONDSTORE capability.
+func (b *Inbox) CleanChangedSince(expunge bool, folder Folder, modSeq uint64, addr ...string) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if err := requireCapability(b, "CONDSTORE"); err != nil {
+		return res, err
+	}
+
+	name, err := serverFolder(b, folder)
+	if err != nil {
+		return res, err
+	}
+
+	status, err := conn(b).Status(string(name), []imap.StatusItem{statusHighestModSeq})
+	if err != nil {
+		return res, err
+	}
+	highest, err := strconv.ParseUint(fmt.Sprint(status.Items[statusHighestModSeq]), 10, 64)
+	if err != nil {
+		return res, fmt.Errorf("inbox: invalid HIGHESTMODSEQ: %w", err)
+	}
+
+	addr, err = normalizeAddresses(addr)
+	if err != nil {
+		return res, err
+	}
+
+	mbox, err := selectFolder(b, folder)
+	if err != nil {
+		return res, err
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchChangedSince(mbox, b, modSeq, messages)
+	}()
+
+	delUIDs := new(imap.SeqSet)
+	var msgMap map[string][]string
+	res.Matched, msgMap = compare(b, addr, messages, delUIDs)
+	printMessagesToDelete(msgMap)
+	res.Unmatched = unmatchedAddresses(addr, msgMap)
+
+	if err := <-errChan; err != nil {
+		return res, err
+	}
+
+	if expunge && res.Matched > 0 {
+		exp, err := deleteMessagesPermanently(b, delUIDs)
+		exp.apply(&res)
+		if err != nil {
+			return res, err
+		}
+	}
+
+	b.SetHighestModSeq(folder, highest)
+
+	return res, nil
+}
+
+// changedSinceFetch is a FETCH command with the CHANGEDSINCE modifier of RFC 7162.
+type changedSinceFetch struct {
+	commands.Fetch
+	modSeq uint64
+}
+
+func (cmd *changedSinceFetch) Command() *imap.Command {
+	c := cmd.Fetch.Command()
+	c.Arguments = append(c.Arguments, []interface{}{
+		imap.RawString("CHANGEDSINCE"),
+		imap.RawString(strconv.FormatUint(cmd.modSeq, 10)),
+	})
+	return c
+}
+
+// fetchChangedSince fetches the envelopes of all messages in the selected mailbox changed after modSeq.
+func fetchChangedSince(mbox *imap.MailboxStatus, b *Inbox, modSeq uint64, messages chan *imap.Message) error {
+	defer close(messages)
+
+	if mbox.Messages == 0 {
+		return nil
+	}
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddRange(1, mbox.Messages)
+	cmd := &changedSinceFetch{
+		Fetch:  commands.Fetch{SeqSet: seqSet, Items: []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope}},
+		modSeq: modSeq,
+	}
+
+	status, err := conn(b).Execute(cmd, &responses.Fetch{Messages: messages, SeqSet: seqSet})
+	if err != nil {
+		return err
+	}
+
+	return status.Err()
+}
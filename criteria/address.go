@@ -0,0 +1,165 @@
+package criteria
+
+import (
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"golang.org/x/net/idna"
+)
+
+// AddressMatcher matches addresses against addresses and domain patterns.
+type AddressMatcher struct {
+	// MatchSubdomains lets a domain pattern like "example.com" also match subdomains like "mail.example.com".
+	MatchSubdomains bool
+}
+
+// MatchAddress reports whether addr matches the pattern, using the default AddressMatcher.
+func MatchAddress(pattern, addr string) bool {
+	return AddressMatcher{}.Match(pattern, addr)
+}
+
+// Match reports whether addr matches the pattern. A pattern without local part,
+// like "@example.com" or "example.com", matches every address of that domain.
+// Comparison is case-insensitive, internationalized domains match in Unicode and punycode form alike.
+func (m AddressMatcher) Match(pattern, addr string) bool {
+	local, domain := splitAddress(addr)
+	if IsDomainPattern(pattern) {
+		return domain != "" && m.matchDomain(strings.TrimPrefix(pattern, "@"), domain)
+	}
+
+	patternLocal, patternDomain := splitAddress(pattern)
+	return strings.EqualFold(patternLocal, local) && CanonicalDomain(patternDomain) == CanonicalDomain(domain)
+}
+
+// matchDomain reports whether host is the pattern domain or, with MatchSubdomains, one of its subdomains.
+// The dot is part of the suffix, so "notexample.com" never matches "example.com".
+func (m AddressMatcher) matchDomain(pattern, host string) bool {
+	pattern, host = CanonicalDomain(pattern), CanonicalDomain(host)
+	return host == pattern || m.MatchSubdomains && strings.HasSuffix(host, "."+pattern)
+}
+
+// CanonicalDomain returns the lowercased punycode form of a domain, like "xn--mnchen-3ya.de" for "München.de".
+// Domains which can't be converted are only lowercased.
+func CanonicalDomain(domain string) string {
+	domain = strings.ToLower(domain)
+	ascii, err := idna.Punycode.ToASCII(domain)
+	if err != nil {
+		return domain
+	}
+
+	return ascii
+}
+
+// splitAddress splits an address at its last "@". The domain is empty for addresses without one.
+func splitAddress(addr string) (local, domain string) {
+	at := strings.LastIndex(addr, "@")
+	if at < 0 {
+		return addr, ""
+	}
+
+	return addr[:at], addr[at+1:]
+}
+
+// domainForms returns the Unicode and the punycode form of an internationalized domain, only domain otherwise.
+func domainForms(domain string) []string {
+	ascii := CanonicalDomain(domain)
+	unicode, err := idna.Punycode.ToUnicode(ascii)
+	if err != nil || unicode == ascii {
+		return []string{domain}
+	}
+
+	return []string{unicode, ascii}
+}
+
+// IsDomainPattern reports whether the pattern has no local part and matches a whole domain.
+func IsDomainPattern(pattern string) bool {
+	return !strings.ContainsAny(pattern, "<> ") && (!strings.Contains(pattern, "@") || strings.HasPrefix(pattern, "@"))
+}
+
+type fromAny struct {
+	matcher  AddressMatcher
+	patterns []string
+}
+
+// FromAny matches messages with a From address matching one of the given addresses or domain patterns.
+func FromAny(addrs ...string) Criteria {
+	return AddressMatcher{}.FromAny(addrs...)
+}
+
+// FromAny is like the package function FromAny, matching with m.
+func (m AddressMatcher) FromAny(addrs ...string) Criteria {
+	return fromAny{matcher: m, patterns: addrs}
+}
+
+func (c fromAny) Search() *imap.SearchCriteria {
+	return headerSearch([]string{"From"}, searchValues(c.patterns))
+}
+
+// headerSearch ORs the search for every value in every header field.
+func headerSearch(fields, values []string) *imap.SearchCriteria {
+	var search *imap.SearchCriteria
+	for _, value := range values {
+		for _, field := range fields {
+			key := imap.NewSearchCriteria()
+			key.Header.Add(field, value)
+			if search == nil {
+				search = key
+				continue
+			}
+
+			or := imap.NewSearchCriteria()
+			or.Or = [][2]*imap.SearchCriteria{{search, key}}
+			search = or
+		}
+	}
+
+	if search == nil {
+		// Nothing can match, but SEARCH needs a key.
+		return searchNone()
+	}
+
+	return search
+}
+
+// searchValues returns the strings searched for in address headers. The raw header may carry an internationalized
+// domain in either form, so both are searched.
+func searchValues(patterns []string) []string {
+	var values []string
+	for _, pattern := range patterns {
+		local, domain := splitAddress(strings.TrimPrefix(pattern, "@"))
+		if domain == "" {
+			// A domain pattern.
+			local, domain = "", local
+		} else {
+			local += "@"
+		}
+
+		for _, form := range domainForms(domain) {
+			values = append(values, local+form)
+		}
+	}
+
+	return values
+}
+
+// MatchesNothing is true without patterns.
+func (c fromAny) MatchesNothing() bool { return len(c.patterns) == 0 }
+
+func (c fromAny) Exact() bool             { return false }
+func (c fromAny) Items() []imap.FetchItem { return []imap.FetchItem{imap.FetchEnvelope} }
+
+func (c fromAny) Match(msg *imap.Message) bool {
+	if msg.Envelope == nil {
+		return false
+	}
+
+	for _, from := range msg.Envelope.From {
+		for _, pattern := range c.patterns {
+			if c.matcher.Match(pattern, from.Address()) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
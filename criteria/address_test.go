@@ -0,0 +1,94 @@
+package criteria
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEmptyPatternsMatchNothing(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Criteria
+	}{
+		{"FromAny", FromAny()},
+		{"RecipientAny", RecipientAny()},
+		{"ListID", ListID()},
+	}
+
+	for _, tt := range tests {
+		if !MatchesNothing(tt.c) {
+			t.Errorf("%s() doesn't match nothing", tt.name)
+		}
+		if got := fmt.Sprint(tt.c.Search().Format()); got != "[NOT [ALL]]" {
+			t.Errorf("%s() searches %s, want [NOT [ALL]]", tt.name, got)
+		}
+	}
+
+	if MatchesNothing(FromAny("example.com")) {
+		t.Error("FromAny with a pattern matches nothing")
+	}
+}
+
+func TestMatchAddressIDN(t *testing.T) {
+	tests := []struct {
+		pattern, addr string
+		want          bool
+	}{
+		{"münchen.de", "info@xn--mnchen-3ya.de", true},
+		{"xn--mnchen-3ya.de", "info@münchen.de", true},
+		{"info@münchen.de", "info@xn--mnchen-3ya.de", true},
+		{"info@xn--mnchen-3ya.de", "info@münchen.de", true},
+		{"@MÜNCHEN.de", "info@xn--mnchen-3ya.de", true},
+		{"XN--MNCHEN-3YA.DE", "info@münchen.de", true},
+		{"xn--mnchen-3ya.de", "info@Xn--Mnchen-3ya.De", true},
+		{"münchen.de", "info@munchen.de", false},
+		{"xn--mnchen-3ya.de", "info@munchen.de", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchAddress(tt.pattern, tt.addr); got != tt.want {
+			t.Errorf("MatchAddress(%q, %q) = %v, want %v", tt.pattern, tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestSearchValuesIDN(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"münchen.de", "[münchen.de xn--mnchen-3ya.de]"},
+		{"XN--MNCHEN-3YA.DE", "[münchen.de xn--mnchen-3ya.de]"},
+		{"info@münchen.de", "[info@münchen.de info@xn--mnchen-3ya.de]"},
+		{"example.com", "[example.com]"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(searchValues([]string{tt.pattern})); got != tt.want {
+			t.Errorf("searchValues(%q) = %s, want %s", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestMatchDomainBoundary(t *testing.T) {
+	tests := []struct {
+		subdomains    bool
+		pattern, addr string
+		want          bool
+	}{
+		{false, "example.com", "a@example.com", true},
+		{false, "example.com", "a@notexample.com", false},
+		{true, "example.com", "a@notexample.com", false},
+		{true, "@example.com", "a@notexample.com", false},
+		{true, "example.com", "a@mail.example.com", true},
+		{false, "example.com", "a@mail.example.com", false},
+		{true, "example.com", "a@example.com.evil.org", false},
+	}
+
+	for _, tt := range tests {
+		m := AddressMatcher{MatchSubdomains: tt.subdomains}
+		if got := m.Match(tt.pattern, tt.addr); got != tt.want {
+			t.Errorf("%+v.Match(%q, %q) = %v, want %v", m, tt.pattern, tt.addr, got, tt.want)
+		}
+	}
+}
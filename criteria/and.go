@@ -0,0 +1,160 @@
+package criteria
+
+import (
+	"github.com/emersion/go-imap"
+)
+
+type and struct {
+	cs []Criteria
+}
+
+// And matches the messages all of cs match.
+func And(cs ...Criteria) Criteria {
+	return and{cs: cs}
+}
+
+func (c and) Search() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	for _, inner := range c.cs {
+		if s := inner.Search(); s != nil {
+			mergeSearch(search, s)
+		}
+	}
+
+	return search
+}
+
+func (c and) Exact() bool {
+	for _, inner := range c.cs {
+		if !inner.Exact() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c and) Items() []imap.FetchItem {
+	var items []imap.FetchItem
+	for _, inner := range c.cs {
+		items = append(items, inner.Items()...)
+	}
+
+	return items
+}
+
+// Match only asks the inexact criteria, the exact ones were already decided by the server.
+func (c and) Match(msg *imap.Message) bool {
+	for _, inner := range c.cs {
+		if !inner.Exact() && !inner.Match(msg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c and) Requires() []string {
+	var caps []string
+	for _, inner := range c.cs {
+		caps = append(caps, Requirements(inner)...)
+	}
+
+	return caps
+}
+
+func (c and) TimeRelative() bool {
+	for _, inner := range c.cs {
+		if IsTimeRelative(inner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c and) MatchesNothing() bool {
+	for _, inner := range c.cs {
+		if MatchesNothing(inner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c and) Resolve(search func(Criteria) ([]uint32, error)) (Criteria, error) {
+	cs, err := resolveAll(c.cs, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return and{cs: cs}, nil
+}
+
+// mergeSearch adds the keys of src to dst, so dst only matches messages matching both.
+func mergeSearch(dst, src *imap.SearchCriteria) {
+	if src.SeqNum != nil || src.Uid != nil {
+		if dst.SeqNum != nil || dst.Uid != nil {
+			// Sets can't be intersected here, NOT (NOT set) keeps both.
+			dst.Not = append(dst.Not, &imap.SearchCriteria{Not: []*imap.SearchCriteria{{SeqNum: src.SeqNum, Uid: src.Uid}}})
+		} else {
+			dst.SeqNum, dst.Uid = src.SeqNum, src.Uid
+		}
+	}
+
+	if !src.Since.IsZero() && src.Since.After(dst.Since) {
+		dst.Since = src.Since
+	}
+	if !src.Before.IsZero() && (dst.Before.IsZero() || src.Before.Before(dst.Before)) {
+		dst.Before = src.Before
+	}
+	if !src.SentSince.IsZero() && src.SentSince.After(dst.SentSince) {
+		dst.SentSince = src.SentSince
+	}
+	if !src.SentBefore.IsZero() && (dst.SentBefore.IsZero() || src.SentBefore.Before(dst.SentBefore)) {
+		dst.SentBefore = src.SentBefore
+	}
+
+	for k, vs := range src.Header {
+		for _, v := range vs {
+			dst.Header.Add(k, v)
+		}
+	}
+	dst.Body = append(dst.Body, src.Body...)
+	dst.Text = append(dst.Text, src.Text...)
+	dst.WithFlags = append(dst.WithFlags, src.WithFlags...)
+	dst.WithoutFlags = append(dst.WithoutFlags, src.WithoutFlags...)
+
+	if src.Larger > dst.Larger {
+		dst.Larger = src.Larger
+	}
+	if src.Smaller != 0 && (dst.Smaller == 0 || src.Smaller < dst.Smaller) {
+		dst.Smaller = src.Smaller
+	}
+
+	dst.Not = append(dst.Not, src.Not...)
+	dst.Or = append(dst.Or, src.Or...)
+}
+
+// RawSearch is used when one of cs searches with raw keys, the keys of all cs are concatenated.
+func (c and) RawSearch() []interface{} {
+	raw := false
+	for _, inner := range c.cs {
+		raw = raw || RawSearchKeys(inner) != nil
+	}
+	if !raw {
+		return nil
+	}
+
+	var keys []interface{}
+	for _, inner := range c.cs {
+		if k := RawSearchKeys(inner); k != nil {
+			keys = append(keys, k...)
+		} else if s := inner.Search(); s != nil {
+			keys = append(keys, s.Format()...)
+		}
+	}
+
+	return keys
+}
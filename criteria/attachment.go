@@ -0,0 +1,226 @@
+package criteria
+
+import (
+	"io"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+type withoutAttachments struct{}
+
+// OnlyWithoutAttachments matches messages made of text parts only, like plain notification mail. Parts with an
+// "attachment" disposition count as attachments whatever their type, as do non-text leaf parts.
+// Inline images referenced by a Content-ID are not attachments: they are part of the HTML body, like the
+// logos of notification mail.
+func OnlyWithoutAttachments() Criteria {
+	return withoutAttachments{}
+}
+
+func (withoutAttachments) Search() *imap.SearchCriteria { return imap.NewSearchCriteria() }
+func (withoutAttachments) Exact() bool                  { return false }
+func (withoutAttachments) Items() []imap.FetchItem {
+	return []imap.FetchItem{imap.FetchBodyStructure}
+}
+
+func (withoutAttachments) Match(msg *imap.Message) bool {
+	return msg.BodyStructure != nil && !HasAttachment(msg.BodyStructure)
+}
+
+// HasAttachment reports whether any part of the body structure is an attachment, as described at OnlyWithoutAttachments.
+func HasAttachment(bs *imap.BodyStructure) bool {
+	found := false
+	bs.Walk(func(path []int, part *imap.BodyStructure) bool {
+		if isAttachment(part) {
+			found = true
+		}
+
+		return !found
+	})
+
+	return found
+}
+
+// isAttachment reports whether a single part is an attachment. Multiparts only contain other parts.
+func isAttachment(part *imap.BodyStructure) bool {
+	disposition := strings.ToLower(part.Disposition)
+	switch {
+	case disposition == "attachment":
+		return true
+	case strings.EqualFold(part.MIMEType, "multipart"), strings.EqualFold(part.MIMEType, "text"):
+		return false
+	case strings.EqualFold(part.MIMEType, "image") && part.Id != "":
+		return false
+	default:
+		return true
+	}
+}
+
+type attachmentName struct {
+	glob string
+}
+
+// AttachmentName matches messages with a part whose file name matches the glob, like "*.exe". The name is taken
+// from the filename parameter of the Content-Disposition or the name parameter of the Content-Type, decoded
+// from RFC 2231 and RFC 2047. The glob syntax is that of path.Match and is matched case-insensitively.
+func AttachmentName(glob string) Criteria {
+	return attachmentName{glob: strings.ToLower(glob)}
+}
+
+func (c attachmentName) Search() *imap.SearchCriteria { return imap.NewSearchCriteria() }
+func (c attachmentName) Exact() bool                  { return false }
+func (c attachmentName) Items() []imap.FetchItem {
+	return []imap.FetchItem{imap.FetchBodyStructure}
+}
+
+func (c attachmentName) Match(msg *imap.Message) bool {
+	return len(MatchingAttachments(msg.BodyStructure, c.glob)) > 0
+}
+
+type attachmentType struct {
+	mimeType string
+}
+
+// AttachmentType matches messages with a part of the MIME type, like "application/x-msdownload". A "*" subtype
+// matches every subtype, e.g. "application/*".
+func AttachmentType(mimeType string) Criteria {
+	return attachmentType{mimeType: strings.ToLower(mimeType)}
+}
+
+func (c attachmentType) Search() *imap.SearchCriteria { return imap.NewSearchCriteria() }
+func (c attachmentType) Exact() bool                  { return false }
+func (c attachmentType) Items() []imap.FetchItem {
+	return []imap.FetchItem{imap.FetchBodyStructure}
+}
+
+func (c attachmentType) Match(msg *imap.Message) bool {
+	return len(MatchingAttachments(msg.BodyStructure, c.mimeType)) > 0
+}
+
+// MatchingAttachments returns the parts of the body structure matching one of the patterns, described by their
+// file name or, without one, their MIME type. Patterns containing a "/" are MIME types as for AttachmentType,
+// all others file name globs as for AttachmentName.
+func MatchingAttachments(bs *imap.BodyStructure, patterns ...string) []string {
+	if bs == nil {
+		return nil
+	}
+
+	var matches []string
+	bs.Walk(func(path []int, part *imap.BodyStructure) bool {
+		if len(part.Parts) > 0 {
+			return true
+		}
+
+		name := PartFilename(part)
+		mimeType := strings.ToLower(part.MIMEType + "/" + part.MIMESubType)
+		for _, pattern := range patterns {
+			pattern = strings.ToLower(pattern)
+			if strings.Contains(pattern, "/") && matchMIMEType(pattern, mimeType) ||
+				!strings.Contains(pattern, "/") && name != "" && matchGlob(pattern, strings.ToLower(name)) {
+				if name == "" {
+					name = mimeType
+				}
+				matches = append(matches, name)
+				break
+			}
+		}
+
+		return true
+	})
+
+	return matches
+}
+
+// matchMIMEType reports whether the MIME type matches the pattern, whose subtype may be "*".
+func matchMIMEType(pattern, mimeType string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return strings.HasPrefix(mimeType, prefix+"/")
+	}
+
+	return pattern == mimeType
+}
+
+// matchGlob reports whether the name matches the glob. Malformed globs match nothing.
+func matchGlob(glob, name string) bool {
+	ok, err := path.Match(glob, name)
+	return err == nil && ok
+}
+
+// PartFilename returns the file name of a part from the filename parameter of its Content-Disposition or the
+// name parameter of its Content-Type. Values split or encoded as of RFC 2231 are joined and decoded.
+func PartFilename(part *imap.BodyStructure) string {
+	if name := param2231(part.DispositionParams, "filename"); name != "" {
+		return name
+	}
+
+	return param2231(part.Params, "name")
+}
+
+// param2231 returns the parameter value, decoding the extended notation of RFC 2231 like
+// filename*=UTF-8'de'%E2%82%AC.pdf and continuations like name*0, name*1. Plain values are returned as they are.
+func param2231(params map[string]string, name string) string {
+	if v, ok := params[name]; ok {
+		return v
+	}
+	if v, ok := params[name+"*"]; ok {
+		return decode2231(v, true)
+	}
+
+	var sb strings.Builder
+	encoded := false
+	for i := 0; ; i++ {
+		key := name + "*" + strconv.Itoa(i)
+		v, ok := params[key]
+		if !ok {
+			if v, ok = params[key+"*"]; !ok {
+				break
+			}
+			encoded = true
+		}
+		sb.WriteString(v)
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+	if !encoded {
+		return sb.String()
+	}
+
+	return decode2231(sb.String(), params[name+"*0*"] != "")
+}
+
+// decode2231 decodes a percent-encoded value, prefixed by charset'language' if withCharset is set.
+// Undecodable values are returned as they are.
+func decode2231(value string, withCharset bool) string {
+	charset := "us-ascii"
+	if withCharset {
+		parts := strings.SplitN(value, "'", 3)
+		if len(parts) != 3 {
+			return value
+		}
+		charset, value = parts[0], parts[2]
+	}
+
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return value
+	}
+	if charset == "" {
+		return unescaped
+	}
+
+	r, err := CharsetReader(charset, strings.NewReader(unescaped))
+	if err != nil {
+		return unescaped
+	}
+	decoded, err := io.ReadAll(r)
+	if err != nil {
+		return unescaped
+	}
+
+	return string(decoded)
+}
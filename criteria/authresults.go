@@ -0,0 +1,153 @@
+package criteria
+
+import (
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// AuthResults are the verdicts of one Authentication-Results header (RFC 8601).
+type AuthResults struct {
+	// ServID is the authserv-id of the host which added the header.
+	ServID string
+	// Results holds all results per method, e.g. "dkim" -> ["pass", "fail"].
+	Results map[string][]string
+}
+
+// Failed reports whether the method has a fail or softfail result and no pass result.
+func (r AuthResults) Failed(method string) bool {
+	failed := false
+	for _, result := range r.Results[strings.ToLower(method)] {
+		switch result {
+		case "pass":
+			return false
+		case "fail", "softfail":
+			failed = true
+		}
+	}
+
+	return failed
+}
+
+// ParseAuthResults parses the value of an Authentication-Results or ARC-Authentication-Results header.
+// Parsing is lenient: comments, unknown properties and malformed result clauses are skipped.
+func ParseAuthResults(value string) AuthResults {
+	res := AuthResults{Results: make(map[string][]string)}
+
+	clauses := strings.Split(stripComments(value), ";")
+	// ARC-Authentication-Results starts with the instance tag.
+	if first := strings.TrimSpace(clauses[0]); strings.HasPrefix(strings.ToLower(first), "i=") && len(clauses) > 1 {
+		clauses = clauses[1:]
+	}
+
+	if fields := strings.Fields(clauses[0]); len(fields) > 0 {
+		res.ServID = strings.ToLower(fields[0])
+	}
+
+	for _, clause := range clauses[1:] {
+		fields := strings.Fields(clause)
+		if len(fields) == 0 {
+			continue
+		}
+
+		method, result, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+
+		// The method may carry a version, like "dkim/1".
+		method, _, _ = strings.Cut(method, "/")
+		method = strings.ToLower(strings.TrimSpace(method))
+		result = strings.ToLower(strings.Trim(result, `"`))
+		if method == "" || result == "" {
+			continue
+		}
+		res.Results[method] = append(res.Results[method], result)
+	}
+
+	return res
+}
+
+// stripComments removes (possibly nested) parenthesized comments.
+func stripComments(s string) string {
+	var sb strings.Builder
+	depth := 0
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"' && depth == 0:
+			quoted = !quoted
+			sb.WriteRune(r)
+		case r == '(' && !quoted:
+			depth++
+		case r == ')' && !quoted && depth > 0:
+			depth--
+		case depth == 0:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+var authResultsSection = HeaderSection("Authentication-Results", "ARC-Authentication-Results")
+
+type authFailed struct {
+	servID string
+	checks []string
+}
+
+// AuthFailed matches messages whose Authentication-Results report a fail or softfail for one of the
+// given checks ("spf", "dkim", "dmarc", all of them when none are given). The topmost header is used,
+// which is the one added by the receiving provider.
+func AuthFailed(checks ...string) Criteria {
+	return AuthFailedBy("", checks...)
+}
+
+// AuthFailedBy is like AuthFailed, but only trusts headers added by the given authserv-id.
+func AuthFailedBy(servID string, checks ...string) Criteria {
+	if len(checks) == 0 {
+		checks = []string{"spf", "dkim", "dmarc"}
+	}
+
+	return authFailed{servID: strings.ToLower(servID), checks: checks}
+}
+
+// TrustedAuthResults returns the Authentication-Results of the message added by servID, or by the receiving
+// provider when servID is empty. ARC-Authentication-Results are only used when no matching plain header exists.
+// ok is false when the message carries no such header.
+func TrustedAuthResults(msg *imap.Message, servID string) (res AuthResults, ok bool) {
+	header := sectionHeader(msg, authResultsSection)
+	for _, name := range []string{"Authentication-Results", "ARC-Authentication-Results"} {
+		for _, value := range header.Values(name) {
+			res := ParseAuthResults(value)
+			if servID == "" || res.ServID == servID {
+				return res, true
+			}
+		}
+	}
+
+	return AuthResults{}, false
+}
+
+func (c authFailed) Search() *imap.SearchCriteria {
+	return headerSearch([]string{"Authentication-Results", "ARC-Authentication-Results"}, []string{""})
+}
+
+func (c authFailed) Exact() bool             { return false }
+func (c authFailed) Items() []imap.FetchItem { return []imap.FetchItem{authResultsSection.FetchItem()} }
+
+func (c authFailed) Match(msg *imap.Message) bool {
+	res, ok := TrustedAuthResults(msg, c.servID)
+	if !ok {
+		return false
+	}
+
+	for _, check := range c.checks {
+		if res.Failed(check) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package criteria
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAuthResults(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  AuthResults
+	}{
+		{
+			name:  "single method",
+			value: "mx.example.org; spf=pass smtp.mailfrom=example.com",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"spf": {"pass"}}},
+		},
+		{
+			name:  "several methods",
+			value: "mx.example.org; dkim=pass header.d=example.com; spf=softfail; dmarc=fail header.from=example.com",
+			want: AuthResults{ServID: "mx.example.org", Results: map[string][]string{
+				"dkim": {"pass"}, "spf": {"softfail"}, "dmarc": {"fail"},
+			}},
+		},
+		{
+			name:  "repeated method",
+			value: "mx.example.org; dkim=fail header.d=a.example; dkim=pass header.d=b.example",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"dkim": {"fail", "pass"}}},
+		},
+		{
+			name:  "version and case",
+			value: "MX.Example.ORG 1; DKIM/1=PASS",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"dkim": {"pass"}}},
+		},
+		{
+			name:  "nested comments",
+			value: "mx.example.org (a (nested; spf=fail) comment); spf=pass (sender (really) ok)",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"spf": {"pass"}}},
+		},
+		{
+			name:  "parenthesis in quoted string",
+			value: `mx.example.org; dkim="pass" header.b="(x)"`,
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"dkim": {"pass"}}},
+		},
+		{
+			name:  "ARC instance tag",
+			value: "i=1; mx.example.org; arc=none; spf=pass",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"arc": {"none"}, "spf": {"pass"}}},
+		},
+		{
+			name:  "no results",
+			value: "mx.example.org; none",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{}},
+		},
+		{
+			name:  "malformed clauses",
+			value: "mx.example.org;; spf ; =pass; dmarc=; dkim=fail",
+			want:  AuthResults{ServID: "mx.example.org", Results: map[string][]string{"dkim": {"fail"}}},
+		},
+		{
+			name:  "empty",
+			value: "",
+			want:  AuthResults{Results: map[string][]string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseAuthResults(tt.value)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseAuthResults(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthResultsFailed(t *testing.T) {
+	tests := []struct {
+		results []string
+		want    bool
+	}{
+		{nil, false},
+		{[]string{"pass"}, false},
+		{[]string{"fail"}, true},
+		{[]string{"softfail"}, true},
+		{[]string{"neutral", "none"}, false},
+		{[]string{"fail", "pass"}, false},
+	}
+
+	for _, tt := range tests {
+		res := AuthResults{Results: map[string][]string{"spf": tt.results}}
+		if got := res.Failed("SPF"); got != tt.want {
+			t.Errorf("Failed with %v = %v, want %v", tt.results, got, tt.want)
+		}
+	}
+}
+
+func TestAuthFailedMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{"plain fail", "Authentication-Results: mx.example.com; dkim=fail\r\n", true},
+		{"plain pass", "Authentication-Results: mx.example.com; dkim=pass\r\n", false},
+		{"ARC only", "ARC-Authentication-Results: i=1; mx.example.com; dkim=fail\r\n", true},
+		{
+			"plain before ARC",
+			"Authentication-Results: mx.example.com; dkim=pass\r\nARC-Authentication-Results: i=1; mx.example.com; dkim=fail\r\n",
+			false,
+		},
+		{"no header", "Subject: hello\r\n", false},
+	}
+
+	for _, tt := range tests {
+		msg := headerMessage(authResultsSection, tt.header)
+		if got := AuthFailed("dkim").Match(msg); got != tt.want {
+			t.Errorf("%s: Match = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
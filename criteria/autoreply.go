@@ -0,0 +1,102 @@
+package criteria
+
+import (
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// autoReplySection fetches the headers auto-responders mark their replies with.
+var autoReplySection = HeaderSection("Auto-Submitted", "X-Autoreply", "X-Autorespond", "Precedence")
+
+type isAutoReply struct{}
+
+// IsAutoReply matches automatic replies like out-of-office notices, marked by Auto-Submitted: auto-replied
+// (RFC 3834), an X-Autoreply or X-Autorespond header or Precedence: auto_reply. Replies written by people never
+// carry these headers, so they aren't matched. See AutoReplySubject for responders which set none of them.
+func IsAutoReply() Criteria {
+	return isAutoReply{}
+}
+
+func (isAutoReply) Search() *imap.SearchCriteria {
+	autoSubmitted := imap.NewSearchCriteria()
+	autoSubmitted.Header.Add("Auto-Submitted", "auto-replied")
+	autoreply := imap.NewSearchCriteria()
+	autoreply.Header.Add("X-Autoreply", "")
+	autorespond := imap.NewSearchCriteria()
+	autorespond.Header.Add("X-Autorespond", "")
+	precedence := imap.NewSearchCriteria()
+	precedence.Header.Add("Precedence", "auto_reply")
+
+	return searchOr(searchOr(autoSubmitted, autoreply), searchOr(autorespond, precedence))
+}
+
+func (isAutoReply) Exact() bool             { return false }
+func (isAutoReply) Items() []imap.FetchItem { return []imap.FetchItem{autoReplySection.FetchItem()} }
+
+func (isAutoReply) Match(msg *imap.Message) bool {
+	header := sectionHeader(msg, autoReplySection)
+	if header == nil {
+		return false
+	}
+
+	for _, v := range header.Values("Auto-Submitted") {
+		if strings.HasPrefix(strings.ToLower(strings.TrimSpace(v)), "auto-replied") {
+			return true
+		}
+	}
+	if len(header.Values("X-Autoreply")) > 0 || len(header.Values("X-Autorespond")) > 0 {
+		return true
+	}
+	for _, v := range header.Values("Precedence") {
+		if strings.EqualFold(strings.TrimSpace(v), "auto_reply") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// autoReplySubjectPrefixes are subject prefixes of common out-of-office notices.
+var autoReplySubjectPrefixes = []string{"Out of Office:", "Automatic reply:", "Autoreply:", "Auto:", "Abwesenheitsnotiz:", "Automatische Antwort:"}
+
+type autoReplySubject struct{}
+
+// AutoReplySubject matches messages whose subject starts like a common out-of-office notice, e.g. "Automatic
+// reply:". Unlike IsAutoReply it is a heuristic and may match replies written by people.
+func AutoReplySubject() Criteria {
+	return autoReplySubject{}
+}
+
+func (autoReplySubject) Search() *imap.SearchCriteria {
+	var search *imap.SearchCriteria
+	for _, prefix := range autoReplySubjectPrefixes {
+		subject := imap.NewSearchCriteria()
+		subject.Header.Add("Subject", prefix)
+		if search == nil {
+			search = subject
+			continue
+		}
+		search = searchOr(search, subject)
+	}
+
+	return search
+}
+
+func (autoReplySubject) Exact() bool             { return false }
+func (autoReplySubject) Items() []imap.FetchItem { return []imap.FetchItem{imap.FetchEnvelope} }
+
+func (autoReplySubject) Match(msg *imap.Message) bool {
+	if msg.Envelope == nil {
+		return false
+	}
+
+	subject := strings.ToLower(DecodeHeader(msg.Envelope.Subject))
+	for _, prefix := range autoReplySubjectPrefixes {
+		if strings.HasPrefix(subject, strings.ToLower(prefix)) {
+			return true
+		}
+	}
+
+	return false
+}
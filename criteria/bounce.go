@@ -0,0 +1,95 @@
+package criteria
+
+import (
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// autoSubmittedSection fetches the Auto-Submitted header of RFC 3834.
+var autoSubmittedSection = HeaderSection("Auto-Submitted")
+
+// bounceSenders are the local parts bounces are commonly sent from.
+var bounceSenders = []string{"mailer-daemon", "postmaster"}
+
+type isBounce struct{}
+
+// IsBounce matches delivery status notifications (RFC 3464). A message is a bounce if it is a multipart/report
+// with report-type=delivery-status, or if it was sent by MAILER-DAEMON or postmaster and carries an
+// Auto-Submitted header other than "no". The subject is never looked at, so mail merely talking about a
+// failed delivery isn't matched.
+func IsBounce() Criteria {
+	return isBounce{}
+}
+
+func (isBounce) Search() *imap.SearchCriteria {
+	report := imap.NewSearchCriteria()
+	report.Header.Add("Content-Type", "report")
+
+	return searchOr(report, headerSearch([]string{"From"}, bounceSenders))
+}
+
+// searchOr ORs two search criteria.
+func searchOr(a, b *imap.SearchCriteria) *imap.SearchCriteria {
+	or := imap.NewSearchCriteria()
+	or.Or = [][2]*imap.SearchCriteria{{a, b}}
+	return or
+}
+
+func (isBounce) Exact() bool { return false }
+func (isBounce) Items() []imap.FetchItem {
+	return []imap.FetchItem{imap.FetchBodyStructure, imap.FetchEnvelope, autoSubmittedSection.FetchItem()}
+}
+
+func (isBounce) Match(msg *imap.Message) bool {
+	if IsDeliveryReport(msg.BodyStructure) {
+		return true
+	}
+
+	return fromBounceSender(msg) && autoSubmitted(msg)
+}
+
+// IsDeliveryReport reports whether the body structure is a multipart/report with report-type=delivery-status.
+func IsDeliveryReport(bs *imap.BodyStructure) bool {
+	return bs != nil && strings.EqualFold(bs.MIMEType, "multipart") && strings.EqualFold(bs.MIMESubType, "report") &&
+		strings.EqualFold(param(bs.Params, "report-type"), "delivery-status")
+}
+
+// param returns the value of a MIME parameter, whose name is case-insensitive.
+func param(params map[string]string, name string) string {
+	for k, v := range params {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// fromBounceSender reports whether the message was sent from one of the bounceSenders.
+func fromBounceSender(msg *imap.Message) bool {
+	if msg.Envelope == nil {
+		return false
+	}
+
+	for _, from := range msg.Envelope.From {
+		for _, sender := range bounceSenders {
+			if strings.EqualFold(from.MailboxName, sender) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// autoSubmitted reports whether the message carries an Auto-Submitted header other than "no".
+func autoSubmitted(msg *imap.Message) bool {
+	for _, value := range HeaderValues(msg, autoSubmittedSection, "Auto-Submitted") {
+		if v := strings.ToLower(strings.TrimSpace(value)); v != "" && !strings.HasPrefix(v, "no") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,16 @@
+package criteria
+
+import "time"
+
+// clock returns the time relative criteria like OlderThan compute their cutoff from.
+var clock = time.Now
+
+// SetClock replaces time.Now for the criteria created afterwards, e.g. to freeze time in tests. nil restores
+// time.Now. It isn't safe for concurrent use with the creation of criteria.
+func SetClock(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+
+	clock = now
+}
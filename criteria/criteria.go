@@ -0,0 +1,207 @@
+// Package criteria describes which messages an inbox operation applies to.
+package criteria
+
+import "github.com/emersion/go-imap"
+
+// Criteria selects messages. Search narrows the candidates down on the server,
+// Match decides on the fetched candidates.
+type Criteria interface {
+	// Search returns search criteria selecting a superset of the matching messages.
+	Search() *imap.SearchCriteria
+	// Exact reports whether every message found by Search matches, so Match doesn't need to be called.
+	Exact() bool
+	// Items returns the fetch items Match needs.
+	Items() []imap.FetchItem
+	// Match reports whether the fetched message matches.
+	Match(msg *imap.Message) bool
+}
+
+// Requirer is implemented by criteria which only work with a server extension.
+type Requirer interface {
+	// Requires returns the capabilities the server has to advertise.
+	Requires() []string
+}
+
+// Requirements returns the capabilities c needs, nil when it works with every server.
+func Requirements(c Criteria) []string {
+	if r, ok := c.(Requirer); ok {
+		return r.Requires()
+	}
+
+	return nil
+}
+
+// RawSearcher is implemented by criteria searching with keys imap.SearchCriteria can't express.
+type RawSearcher interface {
+	// RawSearch returns the search keys, nil to search with Search instead.
+	RawSearch() []interface{}
+}
+
+// RawSearchKeys returns the raw search keys of c, nil when Search is to be used.
+func RawSearchKeys(c Criteria) []interface{} {
+	if r, ok := c.(RawSearcher); ok {
+		return r.RawSearch()
+	}
+
+	return nil
+}
+
+// TimeRelative is implemented by criteria whose matches change as time passes, like OlderThan.
+type TimeRelative interface {
+	// TimeRelative reports whether messages can start matching without being changed.
+	TimeRelative() bool
+}
+
+// IsTimeRelative reports whether c can match messages it didn't match before, only because time passed.
+func IsTimeRelative(c Criteria) bool {
+	r, ok := c.(TimeRelative)
+	return ok && r.TimeRelative()
+}
+
+// Nothing is implemented by criteria which can know in advance that they match no message, like GmailRaw with an
+// empty query.
+type Nothing interface {
+	// MatchesNothing reports whether no message can match, so searching is pointless.
+	MatchesNothing() bool
+}
+
+// MatchesNothing reports whether c is known to match no message.
+func MatchesNothing(c Criteria) bool {
+	n, ok := c.(Nothing)
+	return ok && n.MatchesNothing()
+}
+
+// searchNone returns search criteria matching no message. Empty criteria match all of them, so it is NOT ALL.
+func searchNone() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.Not = []*imap.SearchCriteria{imap.NewSearchCriteria()}
+	return search
+}
+
+// Resolver is implemented by criteria combining operands, whose exact operands have to be searched on their own
+// before Match can decide, like Or of GmailRaw and FromAny.
+type Resolver interface {
+	// Resolve returns the criteria with such exact operands replaced by ByUIDs of the UIDs search returns.
+	Resolve(search func(Criteria) ([]uint32, error)) (Criteria, error)
+}
+
+// Resolve resolves c with search if it is a Resolver and returns c unchanged otherwise.
+func Resolve(c Criteria, search func(Criteria) ([]uint32, error)) (Criteria, error) {
+	if r, ok := c.(Resolver); ok {
+		return r.Resolve(search)
+	}
+
+	return c, nil
+}
+
+// resolveAll resolves every criteria of cs.
+func resolveAll(cs []Criteria, search func(Criteria) ([]uint32, error)) ([]Criteria, error) {
+	resolved := make([]Criteria, len(cs))
+	for i, c := range cs {
+		r, err := Resolve(c, search)
+		if err != nil {
+			return nil, err
+		}
+		resolved[i] = r
+	}
+
+	return resolved, nil
+}
+
+type all struct{}
+
+// All matches every message.
+func All() Criteria {
+	return all{}
+}
+
+func (all) Search() *imap.SearchCriteria { return imap.NewSearchCriteria() }
+func (all) Exact() bool                  { return true }
+func (all) Items() []imap.FetchItem      { return nil }
+func (all) Match(*imap.Message) bool     { return true }
+
+type byUIDs struct {
+	uids *imap.SeqSet
+}
+
+// ByUIDs matches the messages with the given UIDs, e.g. found earlier with Inbox.Find.
+func ByUIDs(uids ...uint32) Criteria {
+	set := new(imap.SeqSet)
+	set.AddNum(uids...)
+	return byUIDs{uids: set}
+}
+
+func (c byUIDs) Search() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.Uid = c.uids
+	return search
+}
+
+func (c byUIDs) Exact() bool                  { return true }
+func (c byUIDs) Items() []imap.FetchItem      { return []imap.FetchItem{imap.FetchUid} }
+func (c byUIDs) Match(msg *imap.Message) bool { return c.uids.Contains(msg.Uid) }
+
+type uidsAbove struct {
+	uid uint32
+}
+
+// UIDsAbove matches the messages with a UID greater than uid, i.e. the messages which arrived later.
+func UIDsAbove(uid uint32) Criteria {
+	return uidsAbove{uid: uid}
+}
+
+func (c uidsAbove) Search() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.Uid = new(imap.SeqSet)
+	search.Uid.AddRange(c.uid+1, 0)
+	return search
+}
+
+// Exact is false, because "n:*" also returns the last message when no UID is greater than n.
+func (c uidsAbove) Exact() bool                  { return false }
+func (c uidsAbove) Items() []imap.FetchItem      { return []imap.FetchItem{imap.FetchUid} }
+func (c uidsAbove) Match(msg *imap.Message) bool { return msg.Uid > c.uid }
+
+type not struct {
+	c Criteria
+}
+
+// Not matches all messages c doesn't match.
+func Not(c Criteria) Criteria {
+	return not{c: c}
+}
+
+func (c not) Search() *imap.SearchCriteria {
+	if !c.c.Exact() {
+		// The server only knows a superset of c, so every message is a candidate.
+		return imap.NewSearchCriteria()
+	}
+
+	search := imap.NewSearchCriteria()
+	search.Not = []*imap.SearchCriteria{c.c.Search()}
+	return search
+}
+
+func (c not) Exact() bool                  { return c.c.Exact() }
+func (c not) Items() []imap.FetchItem      { return c.c.Items() }
+func (c not) Match(msg *imap.Message) bool { return !c.c.Match(msg) }
+func (c not) Requires() []string           { return Requirements(c.c) }
+func (c not) TimeRelative() bool           { return IsTimeRelative(c.c) }
+
+func (c not) Resolve(search func(Criteria) ([]uint32, error)) (Criteria, error) {
+	inner, err := Resolve(c.c, search)
+	if err != nil {
+		return nil, err
+	}
+
+	return not{c: inner}, nil
+}
+
+func (c not) RawSearch() []interface{} {
+	keys := RawSearchKeys(c.c)
+	if keys == nil || !c.c.Exact() {
+		return nil
+	}
+
+	return []interface{}{imap.RawString("NOT"), keys}
+}
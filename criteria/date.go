@@ -0,0 +1,48 @@
+package criteria
+
+import (
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+type olderThan struct {
+	cutoff time.Time
+}
+
+// OlderThan matches messages the server received more than d ago.
+func OlderThan(d time.Duration) Criteria {
+	return olderThan{cutoff: clock().Add(-d)}
+}
+
+// Search uses the day after the cutoff, as SEARCH BEFORE only compares dates. Match decides on the exact time.
+func (c olderThan) Search() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.Before = c.cutoff.AddDate(0, 0, 1)
+	return search
+}
+
+func (c olderThan) Exact() bool                  { return false }
+func (c olderThan) Items() []imap.FetchItem      { return []imap.FetchItem{imap.FetchInternalDate} }
+func (c olderThan) Match(msg *imap.Message) bool { return msg.InternalDate.Before(c.cutoff) }
+func (c olderThan) TimeRelative() bool           { return true }
+
+type since struct {
+	t time.Time
+}
+
+// Since matches messages the server received at or after t.
+func Since(t time.Time) Criteria {
+	return since{t: t}
+}
+
+// Search uses the date of t, as SEARCH SINCE only compares dates. Match decides on the exact time.
+func (c since) Search() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.Since = c.t
+	return search
+}
+
+func (c since) Exact() bool                  { return false }
+func (c since) Items() []imap.FetchItem      { return []imap.FetchItem{imap.FetchInternalDate} }
+func (c since) Match(msg *imap.Message) bool { return !msg.InternalDate.Before(c.t) }
@@ -0,0 +1,46 @@
+package criteria
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: CharsetReader}
+
+// CharsetReader converts text in the given charset to UTF-8. Besides UTF-8 and US-ASCII it knows every charset of
+// the WHATWG encoding standard, like ISO-8859-1, ISO-8859-15 and Windows-1252.
+func CharsetReader(charset string, r io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "us-ascii":
+		return r, nil
+	}
+
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("criteria: unhandled charset %q", charset)
+	}
+
+	return enc.NewDecoder().Reader(r), nil
+}
+
+// DecodeHeader decodes RFC 2047 encoded-words like "=?UTF-8?Q?M=C3=BCnchen?=" which go-imap left in an envelope
+// field because it had no reader for their charset. With imap.CharsetReader set, go-imap already decoded all it
+// could and s is returned unchanged, so text is never decoded twice.
+// Malformed encoded-words or unknown charsets leave s unchanged.
+func DecodeHeader(s string) string {
+	if imap.CharsetReader != nil || !strings.Contains(s, "=?") {
+		return s
+	}
+
+	dec, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return s
+	}
+
+	return dec
+}
@@ -0,0 +1,35 @@
+package criteria
+
+import (
+	"testing"
+
+	"github.com/emersion/go-imap"
+)
+
+func TestDecodeHeader(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"=?UTF-8?Q?M=C3=BCnchen?=", "München"},
+		{"=?windows-1252?Q?caf=E9?=", "café"},
+		{"=?x-unknown?Q?abc?=", "=?x-unknown?Q?abc?="},
+	}
+
+	for _, tt := range tests {
+		if got := DecodeHeader(tt.in); got != tt.want {
+			t.Errorf("DecodeHeader(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeHeaderOnce(t *testing.T) {
+	imap.CharsetReader = CharsetReader
+	defer func() { imap.CharsetReader = nil }()
+
+	// go-imap decoded the envelope already, a literal encoded-word in the decoded text stays.
+	const s = "=?UTF-8?Q?M=C3=BCnchen?="
+	if got := DecodeHeader(s); got != s {
+		t.Errorf("DecodeHeader(%q) with imap.CharsetReader set = %q, want it unchanged", s, got)
+	}
+}
@@ -0,0 +1,71 @@
+package criteria
+
+import (
+	"github.com/emersion/go-imap"
+)
+
+type flags struct {
+	with    []string
+	without []string
+}
+
+// WithFlags matches messages carrying all of the given flags, like imap.SeenFlag.
+func WithFlags(flag ...string) Criteria {
+	return flags{with: flag}
+}
+
+// WithoutFlags matches messages carrying none of the given flags.
+func WithoutFlags(flag ...string) Criteria {
+	return flags{without: flag}
+}
+
+func (c flags) Search() *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.WithFlags = c.with
+	search.WithoutFlags = c.without
+	return search
+}
+
+func (c flags) Exact() bool             { return true }
+func (c flags) Items() []imap.FetchItem { return []imap.FetchItem{imap.FetchFlags} }
+
+func (c flags) Match(msg *imap.Message) bool {
+	has := make(map[string]bool, len(msg.Flags))
+	for _, f := range msg.Flags {
+		has[imap.CanonicalFlag(f)] = true
+	}
+
+	for _, f := range c.with {
+		if !has[imap.CanonicalFlag(f)] {
+			return false
+		}
+	}
+	for _, f := range c.without {
+		if has[imap.CanonicalFlag(f)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Flagged matches messages marked as important. Use Not(Flagged()) to spare them.
+func Flagged() Criteria {
+	return WithFlags(imap.FlaggedFlag)
+}
+
+// Answered matches messages which were replied to.
+func Answered() Criteria {
+	return WithFlags(imap.AnsweredFlag)
+}
+
+// Draft matches messages marked as drafts.
+func Draft() Criteria {
+	return WithFlags(imap.DraftFlag)
+}
+
+// AlreadyDeleted matches messages carrying the \Deleted flag, e.g. flagged by another client but never expunged.
+// Deleting them expunges them, Inbox.RestoreAllInFolder removes the flag again.
+func AlreadyDeleted() Criteria {
+	return WithFlags(imap.DeletedFlag)
+}
@@ -0,0 +1,76 @@
+package criteria
+
+import (
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// GmailCapability is advertised by servers supporting the Gmail IMAP extensions.
+const GmailCapability = "X-GM-EXT-1"
+
+// GmailLabelsItem fetches the Gmail labels of a message.
+const GmailLabelsItem imap.FetchItem = "X-GM-LABELS"
+
+// GmailLabels returns the labels of a message fetched with GmailLabelsItem.
+// System labels keep their backslash, like "\Inbox" or "\Important".
+func GmailLabels(msg *imap.Message) []string {
+	labels, err := imap.ParseStringList(msg.Items[GmailLabelsItem])
+	if err != nil {
+		return nil
+	}
+
+	return labels
+}
+
+type hasGmailLabel struct {
+	name string
+}
+
+// HasGmailLabel matches messages carrying the Gmail label name. It only works on servers advertising X-GM-EXT-1.
+func HasGmailLabel(name string) Criteria {
+	return hasGmailLabel{name: name}
+}
+
+func (c hasGmailLabel) Search() *imap.SearchCriteria { return imap.NewSearchCriteria() }
+func (c hasGmailLabel) Exact() bool                  { return false }
+func (c hasGmailLabel) Items() []imap.FetchItem      { return []imap.FetchItem{GmailLabelsItem} }
+func (c hasGmailLabel) Requires() []string           { return []string{GmailCapability} }
+
+func (c hasGmailLabel) Match(msg *imap.Message) bool {
+	for _, label := range GmailLabels(msg) {
+		if strings.EqualFold(label, c.name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type gmailRaw struct {
+	query string
+}
+
+// GmailRaw matches the messages Gmail's own search finds for query, like "category:promotions older_than:6m".
+// The query is sent as X-GM-RAW and decided by the server alone. It only works on servers advertising X-GM-EXT-1.
+// An empty query matches no message.
+func GmailRaw(query string) Criteria {
+	return gmailRaw{query: query}
+}
+
+// Search is never used, RawSearch takes precedence. It selects nothing in case a caller uses it anyway.
+func (c gmailRaw) Search() *imap.SearchCriteria { return searchNone() }
+
+func (c gmailRaw) Exact() bool              { return true }
+func (c gmailRaw) Items() []imap.FetchItem  { return nil }
+func (c gmailRaw) Match(*imap.Message) bool { return !c.MatchesNothing() }
+func (c gmailRaw) Requires() []string       { return []string{GmailCapability} }
+func (c gmailRaw) MatchesNothing() bool     { return strings.TrimSpace(c.query) == "" }
+
+func (c gmailRaw) RawSearch() []interface{} {
+	if c.MatchesNothing() {
+		return searchNone().Format()
+	}
+
+	return []interface{}{imap.RawString("X-GM-RAW"), c.query}
+}
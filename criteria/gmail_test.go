@@ -0,0 +1,28 @@
+package criteria
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGmailRawEmptyQuery(t *testing.T) {
+	for _, query := range []string{"", "  "} {
+		c := GmailRaw(query)
+		if !MatchesNothing(c) {
+			t.Errorf("GmailRaw(%q) doesn't match nothing", query)
+		}
+		if got := fmt.Sprint(RawSearchKeys(c)); got != "[NOT [ALL]]" {
+			t.Errorf("GmailRaw(%q) searches %s, want [NOT [ALL]]", query, got)
+		}
+	}
+
+	if MatchesNothing(GmailRaw("older_than:1y")) {
+		t.Error("GmailRaw with a query matches nothing")
+	}
+	if !MatchesNothing(And(Flagged(), GmailRaw(""))) {
+		t.Error("And with GmailRaw(\"\") can match")
+	}
+	if MatchesNothing(Or(Flagged(), GmailRaw(""))) {
+		t.Error("Or with another operand than GmailRaw(\"\") matches nothing")
+	}
+}
@@ -0,0 +1,66 @@
+package criteria
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/textproto"
+
+	"github.com/emersion/go-imap"
+)
+
+// HeaderSection returns the body section fetching only the given header fields, without setting \Seen.
+func HeaderSection(fields ...string) *imap.BodySectionName {
+	return &imap.BodySectionName{
+		BodyPartName: imap.BodyPartName{Specifier: imap.HeaderSpecifier, Fields: fields},
+		Peek:         true,
+	}
+}
+
+// HeaderValues returns all values of the header field in the fetched section, in the order of the message.
+func HeaderValues(msg *imap.Message, section *imap.BodySectionName, name string) []string {
+	return sectionHeader(msg, section).Values(name)
+}
+
+// sectionHeader parses the fetched header section, nil if it wasn't fetched or can't be parsed.
+func sectionHeader(msg *imap.Message, section *imap.BodySectionName) textproto.MIMEHeader {
+	body := peekBody(msg, section)
+	if body == nil {
+		return nil
+	}
+
+	header, err := readHeader(body)
+	if err != nil && len(header) == 0 {
+		return nil
+	}
+
+	return header
+}
+
+// peekBody returns a reader of the fetched section without consuming it. go-imap hands out the literal itself,
+// which can only be read once, so it is replaced by a fresh copy for the next criteria reading the same section,
+// like another operand of Or.
+func peekBody(msg *imap.Message, section *imap.BodySectionName) io.Reader {
+	body := msg.GetBody(section)
+	if body == nil {
+		return nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+
+	for s, literal := range msg.Body {
+		if literal == body {
+			msg.Body[s] = bytes.NewReader(data)
+		}
+	}
+
+	return bytes.NewReader(data)
+}
+
+// readHeader parses a fetched header section.
+func readHeader(r io.Reader) (textproto.MIMEHeader, error) {
+	return textproto.NewReader(bufio.NewReader(r)).ReadMIMEHeader()
+}
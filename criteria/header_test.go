@@ -0,0 +1,26 @@
+package criteria
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/emersion/go-imap"
+)
+
+// headerMessage returns a message with the header section fetched like go-imap delivers it, as a literal which
+// can only be read once.
+func headerMessage(section *imap.BodySectionName, header string) *imap.Message {
+	resp := &imap.BodySectionName{BodyPartName: section.BodyPartName}
+	return &imap.Message{Uid: 1, Body: map[*imap.BodySectionName]imap.Literal{
+		resp: bytes.NewBufferString(header + "\r\n"),
+	}}
+}
+
+func TestHeaderValuesRereads(t *testing.T) {
+	msg := headerMessage(listIDSection, "List-Id: Go Nuts <golang-nuts.googlegroups.com>\r\n")
+	for i := 0; i < 2; i++ {
+		if got := HeaderValues(msg, listIDSection, "List-Id"); len(got) != 1 {
+			t.Errorf("read %d: HeaderValues = %v, want the List-Id", i+1, got)
+		}
+	}
+}
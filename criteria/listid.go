@@ -0,0 +1,58 @@
+package criteria
+
+import (
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// listIDSection fetches the List-Id header of RFC 2919.
+var listIDSection = HeaderSection("List-Id")
+
+type listID struct {
+	ids []string
+}
+
+// ListID matches mailing list messages whose List-Id is one of ids. IDs are compared case-insensitively and may be
+// given with a phrase and angle brackets like the header, "Go Nuts <golang-nuts.googlegroups.com>".
+func ListID(ids ...string) Criteria {
+	normalized := make([]string, len(ids))
+	for i, id := range ids {
+		normalized[i] = NormalizeListID(id)
+	}
+
+	return listID{ids: normalized}
+}
+
+// NormalizeListID returns the bare, lowercased list identifier of a List-Id value.
+func NormalizeListID(value string) string {
+	value = strings.TrimSpace(value)
+	if start := strings.LastIndex(value, "<"); start >= 0 {
+		if end := strings.Index(value[start:], ">"); end > 0 {
+			value = value[start+1 : start+end]
+		}
+	}
+
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+func (c listID) Search() *imap.SearchCriteria {
+	return headerSearch([]string{"List-Id"}, c.ids)
+}
+
+func (c listID) Exact() bool             { return false }
+func (c listID) MatchesNothing() bool    { return len(c.ids) == 0 }
+func (c listID) Items() []imap.FetchItem { return []imap.FetchItem{listIDSection.FetchItem()} }
+
+func (c listID) Match(msg *imap.Message) bool {
+	for _, value := range HeaderValues(msg, listIDSection, "List-Id") {
+		id := NormalizeListID(value)
+		for _, want := range c.ids {
+			if id == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,166 @@
+package criteria
+
+import (
+	"github.com/emersion/go-imap"
+)
+
+type or struct {
+	cs []Criteria
+}
+
+// Or matches the messages at least one of cs matches, with a single SEARCH on the server. Or without criteria
+// matches no message.
+func Or(cs ...Criteria) Criteria {
+	return or{cs: cs}
+}
+
+func (c or) Search() *imap.SearchCriteria {
+	searches := make([]*imap.SearchCriteria, len(c.cs))
+	for i, inner := range c.cs {
+		if searches[i] = inner.Search(); searches[i] == nil {
+			searches[i] = imap.NewSearchCriteria()
+		}
+	}
+
+	return OrSearch(searches...)
+}
+
+func (c or) Exact() bool {
+	for _, inner := range c.cs {
+		if !inner.Exact() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c or) Items() []imap.FetchItem {
+	var items []imap.FetchItem
+	for _, inner := range c.cs {
+		items = append(items, inner.Items()...)
+	}
+
+	return items
+}
+
+// Match asks every criteria, as the server only decided on the union of them. Exact criteria like GmailRaw can't
+// decide on their own, Resolve replaces them by their UIDs first.
+func (c or) Match(msg *imap.Message) bool {
+	for _, inner := range c.cs {
+		if inner.Match(msg) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c or) Requires() []string {
+	var caps []string
+	for _, inner := range c.cs {
+		caps = append(caps, Requirements(inner)...)
+	}
+
+	return caps
+}
+
+func (c or) TimeRelative() bool {
+	for _, inner := range c.cs {
+		if IsTimeRelative(inner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Resolve searches the exact criteria with a single search of their own when they are mixed with inexact ones.
+func (c or) Resolve(search func(Criteria) ([]uint32, error)) (Criteria, error) {
+	cs, err := resolveAll(c.cs, search)
+	if err != nil {
+		return nil, err
+	}
+
+	var exact, inexact []Criteria
+	for _, inner := range cs {
+		if inner.Exact() {
+			exact = append(exact, inner)
+		} else {
+			inexact = append(inexact, inner)
+		}
+	}
+	if len(exact) == 0 || len(inexact) == 0 {
+		return or{cs: cs}, nil
+	}
+
+	uids, err := search(or{cs: exact})
+	if err != nil {
+		return nil, err
+	}
+	if len(uids) > 0 {
+		inexact = append(inexact, ByUIDs(uids...))
+	}
+
+	return or{cs: inexact}, nil
+}
+
+// MatchesNothing is true without criteria or if none of them can match.
+func (c or) MatchesNothing() bool {
+	for _, inner := range c.cs {
+		if !MatchesNothing(inner) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RawSearch is used when one of cs searches with raw keys, the keys of every cs become an operand of OR.
+func (c or) RawSearch() []interface{} {
+	raw := false
+	for _, inner := range c.cs {
+		raw = raw || RawSearchKeys(inner) != nil
+	}
+	if !raw {
+		return nil
+	}
+
+	operands := make([][]interface{}, len(c.cs))
+	for i, inner := range c.cs {
+		if k := RawSearchKeys(inner); k != nil {
+			operands[i] = k
+		} else if s := inner.Search(); s != nil {
+			operands[i] = s.Format()
+		} else {
+			operands[i] = imap.NewSearchCriteria().Format()
+		}
+	}
+
+	return orKeys(operands)
+}
+
+// orKeys nests the operands into OR keys of two operands each, like OrSearch.
+func orKeys(operands [][]interface{}) []interface{} {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+
+	mid := len(operands) / 2
+	return []interface{}{imap.RawString("OR"), orKeys(operands[:mid]), orKeys(operands[mid:])}
+}
+
+// OrSearch returns search criteria matching the messages at least one of searches matches. SEARCH only knows
+// OR with two operands, so they are nested as a balanced tree, keeping the nesting shallow for servers limiting
+// it. Without searches, no message matches.
+func OrSearch(searches ...*imap.SearchCriteria) *imap.SearchCriteria {
+	switch len(searches) {
+	case 0:
+		return searchNone()
+	case 1:
+		return searches[0]
+	}
+
+	mid := len(searches) / 2
+	return searchOr(OrSearch(searches[:mid]...), OrSearch(searches[mid:]...))
+}
@@ -0,0 +1,160 @@
+package criteria
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/emersion/go-imap"
+)
+
+func flagSearch(flag string) *imap.SearchCriteria {
+	search := imap.NewSearchCriteria()
+	search.WithFlags = []string{flag}
+	return search
+}
+
+func TestOrSearch(t *testing.T) {
+	tests := []struct {
+		flags []string
+		want  string
+	}{
+		{nil, "[NOT [ALL]]"},
+		{[]string{`\Seen`}, "[SEEN]"},
+		{[]string{`\Seen`, `\Flagged`}, "[OR [SEEN] [FLAGGED]]"},
+		{[]string{`\Seen`, `\Flagged`, `\Draft`}, "[OR [SEEN] [OR [FLAGGED] [DRAFT]]]"},
+		{[]string{`\Seen`, `\Flagged`, `\Draft`, `\Answered`}, "[OR [OR [SEEN] [FLAGGED]] [OR [DRAFT] [ANSWERED]]]"},
+	}
+
+	for _, tt := range tests {
+		searches := make([]*imap.SearchCriteria, len(tt.flags))
+		for i, flag := range tt.flags {
+			searches[i] = flagSearch(flag)
+		}
+
+		got := fmt.Sprint(OrSearch(searches...).Format())
+		if got != tt.want {
+			t.Errorf("OrSearch(%v) = %s, want %s", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestOrExact(t *testing.T) {
+	tests := []struct {
+		name string
+		c    Criteria
+		want bool
+	}{
+		{"no operands", Or(), true},
+		{"exact operands", Or(Flagged(), Answered()), true},
+		{"mixed operands", Or(Flagged(), FromAny("example.com")), false},
+		{"inexact operands", Or(FromAny("example.com"), OlderThan(0)), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.c.Exact(); got != tt.want {
+			t.Errorf("%s: Exact() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func fromMessage(uid uint32, mailbox, host string) *imap.Message {
+	msg := &imap.Message{Uid: uid, Envelope: &imap.Envelope{}}
+	msg.Envelope.From = []*imap.Address{{MailboxName: mailbox, HostName: host}}
+	return msg
+}
+
+func TestOrMatch(t *testing.T) {
+	c := Or(FromAny("example.com"), FromAny("alice@example.org"))
+	tests := []struct {
+		msg  *imap.Message
+		want bool
+	}{
+		{fromMessage(1, "bob", "example.com"), true},
+		{fromMessage(2, "alice", "example.org"), true},
+		{fromMessage(3, "bob", "example.org"), false},
+		{fromMessage(4, "bob", "notexample.com"), false},
+	}
+
+	for _, tt := range tests {
+		if got := c.Match(tt.msg); got != tt.want {
+			t.Errorf("Match(%s) = %v, want %v", tt.msg.Envelope.From[0].Address(), got, tt.want)
+		}
+	}
+
+	if Or().Match(fromMessage(1, "bob", "example.com")) {
+		t.Error("Or() matched a message")
+	}
+}
+
+func TestOrRawSearch(t *testing.T) {
+	if keys := Or(Flagged(), Answered()).(RawSearcher).RawSearch(); keys != nil {
+		t.Errorf("RawSearch() without raw operands = %v, want nil", keys)
+	}
+
+	got := fmt.Sprint(RawSearchKeys(Or(GmailRaw("older_than:1y"), Flagged())))
+	want := "[OR [X-GM-RAW older_than:1y] [FLAGGED]]"
+	if got != want {
+		t.Errorf("RawSearch() = %s, want %s", got, want)
+	}
+}
+
+func TestOrResolve(t *testing.T) {
+	var searched []string
+	search := func(c Criteria) ([]uint32, error) {
+		searched = append(searched, fmt.Sprint(RawSearchKeys(c)))
+		return []uint32{7}, nil
+	}
+
+	c, err := Resolve(Or(GmailRaw("category:promotions"), FromAny("example.com")), search)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(searched) != 1 || searched[0] != "[X-GM-RAW category:promotions]" {
+		t.Errorf("searched %v, want only the GmailRaw operand", searched)
+	}
+
+	tests := []struct {
+		msg  *imap.Message
+		want bool
+	}{
+		{fromMessage(7, "news", "shop.example"), true},
+		{fromMessage(8, "bob", "example.com"), true},
+		{fromMessage(9, "a", "notexample.com"), false},
+	}
+	for _, tt := range tests {
+		if got := c.Match(tt.msg); got != tt.want {
+			t.Errorf("Match(UID %d, %s) = %v, want %v", tt.msg.Uid, tt.msg.Envelope.From[0].Address(), got, tt.want)
+		}
+	}
+}
+
+func TestOrResolveNested(t *testing.T) {
+	calls := 0
+	search := func(Criteria) ([]uint32, error) {
+		calls++
+		return nil, nil
+	}
+
+	c, err := Resolve(Not(And(OlderThan(0), Or(GmailRaw("x"), FromAny("example.com")))), search)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("search called %d times, want 1", calls)
+	}
+	if c.Exact() {
+		t.Error("resolved criteria became exact")
+	}
+
+	calls = 0
+	if _, err := Resolve(Or(Flagged(), Answered()), search); err != nil || calls != 0 {
+		t.Errorf("Resolve of exact operands searched %d times, err %v", calls, err)
+	}
+}
+
+func TestOrMatchSharedSection(t *testing.T) {
+	msg := headerMessage(listIDSection, "List-Id: <b.example.com>\r\n")
+	if !Or(ListID("a.example.com"), ListID("b.example.com")).Match(msg) {
+		t.Error("Or(ListID(a), ListID(b)) didn't match the List-Id of b, the first operand consumed the header")
+	}
+}
@@ -0,0 +1,77 @@
+package criteria
+
+import (
+	"net/mail"
+
+	"github.com/emersion/go-imap"
+)
+
+// bccSection fetches the Bcc header, which servers often leave out of the envelope.
+var bccSection = HeaderSection("Bcc")
+
+type recipientAny struct {
+	matcher  AddressMatcher
+	patterns []string
+}
+
+// RecipientAny matches messages with a To, Cc or Bcc address matching one of the given addresses or domain patterns.
+func RecipientAny(addrs ...string) Criteria {
+	return AddressMatcher{}.RecipientAny(addrs...)
+}
+
+// RecipientAny is like the package function RecipientAny, matching with m.
+func (m AddressMatcher) RecipientAny(addrs ...string) Criteria {
+	return recipientAny{matcher: m, patterns: addrs}
+}
+
+func (c recipientAny) Search() *imap.SearchCriteria {
+	return headerSearch([]string{"To", "Cc", "Bcc"}, searchValues(c.patterns))
+}
+
+func (c recipientAny) Exact() bool          { return false }
+func (c recipientAny) MatchesNothing() bool { return len(c.patterns) == 0 }
+func (c recipientAny) Items() []imap.FetchItem {
+	return []imap.FetchItem{imap.FetchEnvelope, bccSection.FetchItem()}
+}
+
+func (c recipientAny) Match(msg *imap.Message) bool {
+	for _, addr := range Recipients(msg) {
+		for _, pattern := range c.patterns {
+			if c.matcher.Match(pattern, addr) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Recipients returns the To, Cc and Bcc addresses of a message fetched with the items of RecipientAny.
+// The Bcc header is used when the envelope has no Bcc addresses. It can only be read once.
+func Recipients(msg *imap.Message) []string {
+	if msg.Envelope == nil {
+		return nil
+	}
+
+	var addrs []string
+	for _, list := range [][]*imap.Address{msg.Envelope.To, msg.Envelope.Cc, msg.Envelope.Bcc} {
+		for _, addr := range list {
+			addrs = append(addrs, addr.Address())
+		}
+	}
+
+	if len(msg.Envelope.Bcc) == 0 {
+		for _, value := range HeaderValues(msg, bccSection, "Bcc") {
+			list, err := mail.ParseAddressList(value)
+			if err != nil {
+				continue
+			}
+
+			for _, addr := range list {
+				addrs = append(addrs, addr.Address)
+			}
+		}
+	}
+
+	return addrs
+}
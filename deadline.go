@@ -0,0 +1,50 @@
+package inbox
+
+import (
+	"log"
+	"time"
+)
+
+// deadlineBatchSize is the number of messages fetched at once while a deadline is set.
+const deadlineBatchSize = 500
+
+// SetDeadline bounds the duration of later operations, e.g. for scheduled runs with a time budget. Once the
+// deadline passes, no further batches of messages are fetched and no further folders are started, but the
+// deletions already identified are still carried out. Results cut short are marked as Truncated.
+// The zero time removes the deadline.
+func (b *Inbox) SetDeadline(deadline time.Time) {
+	b.deadline = deadline
+}
+
+// stopEarly reports whether the deadline passed or the budget of WithBudget is used up, and marks the running
+// operation as truncated if so.
+func stopEarly(b *Inbox) bool {
+	exhausted := budgetExhausted(b)
+	if !exhausted && (b.deadline.IsZero() || now(b).Before(b.deadline)) {
+		return false
+	}
+
+	if !b.truncated {
+		if exhausted {
+			logBudget(b)
+		} else {
+			log.Println("Deadline reached, stopping early")
+		}
+	}
+	b.truncated = true
+	return true
+}
+
+// fetchBatches splits the UIDs into the batches they are fetched in, a single one without deadline or budget.
+func fetchBatches(b *Inbox, uids []uint32) [][]uint32 {
+	if b.deadline.IsZero() && b.budget == (Budget{}) {
+		return [][]uint32{uids}
+	}
+
+	var batches [][]uint32
+	for start := 0; start < len(uids); start += deadlineBatchSize {
+		batches = append(batches, uids[start:min(start+deadlineBatchSize, len(uids))])
+	}
+
+	return batches
+}
@@ -0,0 +1,54 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+func TestDeadlineTruncates(t *testing.T) {
+	s := newTestServer(t)
+	old := time.Now().AddDate(-1, 0, 0)
+	s.addMessage(t, "INBOX", "a@example.com", "inbox", old)
+	s.addMessage(t, "Sent", "username@example.org", "sent", old)
+	s.addMessage(t, "Drafts", "username@example.org", "draft", old)
+
+	tests := []struct {
+		name string
+		op   func(b *Inbox) (DeleteResult, error)
+	}{
+		{"Delete", func(b *Inbox) (DeleteResult, error) {
+			return b.Delete(false, "INBOX", criteria.FromAny("a@example.com"))
+		}},
+		{"DeleteMatchingAny", func(b *Inbox) (DeleteResult, error) {
+			return b.DeleteMatchingAny(false, "INBOX", criteria.FromAny("a@example.com"), criteria.Flagged())
+		}},
+		{"DeleteSentTo", func(b *Inbox) (DeleteResult, error) {
+			res, err := b.DeleteSentTo(false, "username@example.org")
+			return res.DeleteResult, err
+		}},
+		{"DeleteOldDrafts", func(b *Inbox) (DeleteResult, error) {
+			res, err := b.DeleteOldDrafts(false, time.Hour)
+			return res.DeleteResult, err
+		}},
+	}
+
+	for _, tt := range tests {
+		b := s.dial(t)
+		b.SetDeadline(time.Now().Add(-time.Minute))
+
+		res, err := tt.op(b)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !res.Truncated || res.Matched != 0 {
+			t.Errorf("%s past the deadline: Truncated %v, Matched %d, want a truncated empty result", tt.name, res.Truncated, res.Matched)
+		}
+
+		b.SetDeadline(time.Time{})
+		if res, err := tt.op(b); err != nil || res.Truncated || res.Matched != 1 {
+			t.Errorf("%s without deadline: Truncated %v, Matched %d, err %v, want one match", tt.name, res.Truncated, res.Matched, err)
+		}
+	}
+}
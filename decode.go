@@ -0,0 +1,13 @@
+package inbox
+
+import (
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// UseCharsetReader lets go-imap itself decode encoded-words of every charset criteria.CharsetReader knows, not only
+// UTF-8 and ISO-8859-1. It replaces the process-wide imap.CharsetReader, so call it once before connecting if the
+// application wants that. It isn't needed for this package, criteria.DecodeHeader decodes what go-imap left.
+func UseCharsetReader() {
+	imap.CharsetReader = criteria.CharsetReader
+}
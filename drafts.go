@@ -0,0 +1,70 @@
+package inbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// DraftResult is the outcome of DeleteOldDrafts.
+type DraftResult struct {
+	DeleteResult
+	// Drafts are the matched drafts in the order they were found.
+	Drafts []Draft
+}
+
+// Draft describes a draft matched by DeleteOldDrafts.
+type Draft struct {
+	UID     uint32
+	Subject string
+	// Saved is the INTERNALDATE, the time the draft was last saved.
+	Saved time.Time
+}
+
+// DeleteOldDrafts deletes the drafts last saved more than olderThan ago from the Drafts folder of the account.
+// The INTERNALDATE is compared, as a draft's Date header is often missing and mail clients append every saved
+// version anew. \Recent drafts are kept. The subject and save time of every draft are logged and returned.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteOldDrafts(expunge bool, olderThan time.Duration) (res DraftResult, err error) {
+	defer operation(b)()
+	folder, err := b.ResolveFolder(WellKnownDrafts)
+	if err != nil {
+		return DraftResult{DeleteResult: DeleteResult{Folder: WellKnownDrafts}}, err
+	}
+
+	res = DraftResult{DeleteResult: DeleteResult{Folder: folder}}
+	defer func(start time.Time) { observeDelete(b, start, &res.DeleteResult, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	log.Println("Drafts to delete in", folder+":")
+	crit := criteria.And(criteria.OlderThan(olderThan), criteria.WithoutFlags(imap.RecentFlag))
+	delUIDs := new(imap.SeqSet)
+	err = findMessages(b, crit, []imap.FetchItem{imap.FetchEnvelope, imap.FetchInternalDate}, func(msg *imap.Message) bool {
+		subject := ""
+		if msg.Envelope != nil {
+			subject = criteria.DecodeHeader(msg.Envelope.Subject)
+		}
+		log.Println("\t", msg.InternalDate.Format(time.DateTime), subject)
+		res.Drafts = append(res.Drafts, Draft{UID: msg.Uid, Subject: subject, Saved: msg.InternalDate})
+
+		delUIDs.AddNum(msg.Uid)
+		res.Matched++
+		return true
+	})
+	if err != nil {
+		return res, err
+	}
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res.DeleteResult)
+	return res, err
+}
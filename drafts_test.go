@@ -0,0 +1,28 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeleteOldDraftsReportsDrafts(t *testing.T) {
+	saved := time.Now().Add(-48 * time.Hour).Truncate(time.Second)
+
+	s := newTestServer(t)
+	old := s.addMessage(t, "Drafts", "username@example.org", "unfinished reply", saved)
+	s.addMessage(t, "Drafts", "username@example.org", "fresh draft", time.Now())
+	b := s.dial(t)
+
+	res, err := b.DeleteOldDrafts(false, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matched != 1 || len(res.Drafts) != 1 {
+		t.Fatalf("matched %d drafts, reported %v, want the old one", res.Matched, res.Drafts)
+	}
+
+	d := res.Drafts[0]
+	if d.UID != old || d.Subject != "unfinished reply" || !d.Saved.Equal(saved) {
+		t.Errorf("draft = %+v, want UID %d, subject \"unfinished reply\" and saved %v", d, old, saved)
+	}
+}
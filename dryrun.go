@@ -0,0 +1,24 @@
+package inbox
+
+import "log"
+
+// SetDryRun makes every destructive operation run as usual but skip its final STORE, EXPUNGE, MOVE, COPY or
+// APPEND. Results still report what would have been matched, while Deleted stays zero.
+func (b *Inbox) SetDryRun(dryRun bool) {
+	b.dryRun = dryRun
+}
+
+// DryRun reports whether destructive operations are skipped.
+func (b *Inbox) DryRun() bool {
+	return b.dryRun
+}
+
+// skipMutation reports whether the mutation described by the arguments must be skipped for a dry run and logs it.
+func skipMutation(b *Inbox, v ...interface{}) bool {
+	if !b.dryRun {
+		return false
+	}
+
+	log.Println(append([]interface{}{"Dry run, skipped:"}, v...)...)
+	return true
+}
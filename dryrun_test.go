@@ -0,0 +1,79 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+func TestDryRunSendsNoMutation(t *testing.T) {
+	s := newTestServer(t)
+	s.mailbox(t, "Other")
+	first := s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	last := s.addMessage(t, "Archive", "b@example.com", "second", time.Now())
+	b := s.dial(t)
+	b.SetDryRun(true)
+	s.log.Reset()
+
+	ops := map[string]func() error{
+		"Delete": func() error {
+			_, err := b.Delete(true, "Archive", criteria.FromAny("a@example.com"))
+			return err
+		},
+		"DeleteMatchingAny": func() error {
+			_, err := b.DeleteMatchingAny(true, "Archive", criteria.FromAny("a@example.com"), criteria.FromAny("b@example.com"))
+			return err
+		},
+		"DeleteUIDRange": func() error {
+			_, err := b.DeleteUIDRange(true, "Archive", first, last)
+			return err
+		},
+		"DeleteMessagesInFolderFromAddress": func() error {
+			return b.DeleteMessagesInFolderFromAddress(true, "Archive", "a@example.com")
+		},
+		"DeleteMessagesInFolderNotFromAddress": func() error {
+			_, err := b.DeleteMessagesInFolderNotFromAddress(true, "Archive", "a@example.com")
+			return err
+		},
+		"DeleteAllMessagesInFolder": func() error {
+			return b.DeleteAllMessagesInFolder(true, "Archive")
+		},
+		"Move": func() error {
+			_, err := b.Move("Archive", "Other", criteria.FromAny("a@example.com"))
+			return err
+		},
+		"Copy": func() error {
+			_, err := b.Copy("Archive", "Other", criteria.FromAny("a@example.com"))
+			return err
+		},
+		"MoveRange": func() error {
+			_, err := b.MoveRange("Archive", "Other", first, last)
+			return err
+		},
+		"FlagMessagesFromAddress": func() error {
+			_, err := b.FlagMessagesFromAddress("Archive", "keep", "a@example.com")
+			return err
+		},
+	}
+
+	for name, op := range ops {
+		if err := op(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		for _, cmd := range s.commands() {
+			switch cmd {
+			case "STORE", "UID STORE", "EXPUNGE", "UID EXPUNGE", "MOVE", "UID MOVE", "COPY", "UID COPY", "APPEND":
+				t.Errorf("%s sent %s in a dry run", name, cmd)
+			}
+		}
+		s.log.Reset()
+	}
+
+	if n := len(s.mailbox(t, "Archive").Messages); n != 2 {
+		t.Errorf("Archive holds %d messages after the dry run, want 2", n)
+	}
+	if n := len(s.mailbox(t, "Other").Messages); n != 0 {
+		t.Errorf("Other holds %d messages after the dry run, want 0", n)
+	}
+}
@@ -0,0 +1,84 @@
+package inbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// DeleteCrossFolderDuplicates deletes the messages of the scanned folders whose Message-ID is also present in keep,
+// so every copy but the one in keep is removed. Messages without Message-ID are never deleted. keep is examined
+// read-only and skipped if it's among the scanned folders.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteCrossFolderDuplicates(expunge bool, keep Folder, scan ...Folder) (map[Folder]DeleteResult, error) {
+	defer operation(b)()
+	kept, err := folderMessageIDs(b, keep)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make([]Folder, 0, len(scan))
+	for _, folder := range scan {
+		// A folder failing to resolve is left to forEachFolder, which reports it.
+		if same, err := sameFolder(b, folder, keep); err != nil || !same {
+			folders = append(folders, folder)
+		}
+	}
+
+	return forEachFolder(b, folders, func(folder Folder) (DeleteResult, error) {
+		return deleteDuplicatesOf(b, expunge, folder, kept)
+	})
+}
+
+// folderMessageIDs returns the normalized Message-IDs of all messages in the folder.
+func folderMessageIDs(b *Inbox, folder Folder) (map[string]bool, error) {
+	if _, err := examineFolder(b, folder); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]bool)
+	err := findMessages(b, criteria.All(), []imap.FetchItem{imap.FetchEnvelope}, func(msg *imap.Message) bool {
+		if msg.Envelope != nil && msg.Envelope.MessageId != "" {
+			ids[normalizeMessageID(msg.Envelope.MessageId)] = true
+		}
+		return true
+	})
+
+	return ids, err
+}
+
+// deleteDuplicatesOf deletes the messages of the folder whose Message-ID is one of ids.
+func deleteDuplicatesOf(b *Inbox, expunge bool, folder Folder, ids map[string]bool) (res DeleteResult, err error) {
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	delUIDs := new(imap.SeqSet)
+	err = findMessages(b, criteria.All(), []imap.FetchItem{imap.FetchEnvelope}, func(msg *imap.Message) bool {
+		if msg.Envelope == nil || msg.Envelope.MessageId == "" || !ids[normalizeMessageID(msg.Envelope.MessageId)] {
+			return true
+		}
+
+		log.Println("\t", msg.Envelope.MessageId, criteria.DecodeHeader(msg.Envelope.Subject))
+		delUIDs.AddNum(msg.Uid)
+		res.Matched++
+		return true
+	})
+	if err != nil {
+		return res, err
+	}
+	log.Println("Duplicates to delete in", folder+":", res.Matched)
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	return res, err
+}
@@ -0,0 +1,161 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// specialUseAttrs are the SPECIAL-USE attributes (RFC 6154) of folders which are never deleted.
+var specialUseAttrs = []string{imap.AllAttr, imap.ArchiveAttr, imap.DraftsAttr, imap.FlaggedAttr, imap.JunkAttr, imap.SentAttr, imap.TrashAttr}
+
+// FindEmptyFolders returns the selectable folders without any message, using STATUS.
+func (b *Inbox) FindEmptyFolders() ([]Folder, error) {
+	defer operation(b)()
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var empty []Folder
+	for _, mbox := range emptyMailboxes(b, mailboxes) {
+		empty = append(empty, logicalFolder(b, mbox.Name))
+	}
+
+	return empty, nil
+}
+
+// emptyMailboxes returns the selectable mailboxes without any message. Mailboxes whose STATUS fails are skipped.
+func emptyMailboxes(b *Inbox, mailboxes []*imap.MailboxInfo) []*imap.MailboxInfo {
+	var empty []*imap.MailboxInfo
+	for _, mbox := range mailboxes {
+		if !selectable(mbox) {
+			continue
+		}
+
+		n, err := messageCount(b, mbox.Name)
+		if err != nil {
+			log.Println("Skipping", mbox.Name+":", err)
+			continue
+		}
+
+		if n == 0 {
+			empty = append(empty, mbox)
+		}
+	}
+
+	return empty
+}
+
+// DeleteEmptyFolders deletes the empty folders except the excluded ones and returns the deleted folders.
+// Children are deleted before their parents, parents with remaining children are kept. The INBOX and special-use
+// folders like the trash are never deleted, nor are folders which received messages since they were found empty.
+// Folders failing to delete don't stop the run unless the error is fatal, see isFatal.
+func (b *Inbox) DeleteEmptyFolders(exclude []Folder) ([]Folder, error) {
+	defer operation(b)()
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	protected, err := protectedFolders(b)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make(map[string]bool, len(mailboxes))
+	for _, mbox := range mailboxes {
+		remaining[mbox.Name] = true
+	}
+
+	empty := emptyMailboxes(b, mailboxes)
+	sort.SliceStable(empty, func(i, j int) bool { return folderDepth(empty[i]) > folderDepth(empty[j]) })
+
+	var deleted []Folder
+	var errs []error
+	for _, mbox := range empty {
+		folder := logicalFolder(b, mbox.Name)
+		switch {
+		case protected(mbox), folderExcluded(string(folder), exclude), hasChildren(mbox, remaining):
+			continue
+		}
+
+		// Messages may have arrived since the folder was found empty.
+		if n, err := messageCount(b, mbox.Name); err != nil || n > 0 {
+			continue
+		}
+
+		if skipMutation(b, "delete empty folder", folder) {
+			continue
+		}
+
+		invalidateSelected(b)
+		if err := conn(b).Delete(mbox.Name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				break
+			}
+			continue
+		}
+
+		log.Println("Deleted empty folder", folder)
+		delete(remaining, mbox.Name)
+		deleted = append(deleted, folder)
+	}
+
+	return deleted, errors.Join(errs...)
+}
+
+// protectedFolders returns a func reporting whether a mailbox is the INBOX or a special-use folder, detected by
+// its attribute or its name.
+func protectedFolders(b *Inbox) (func(*imap.MailboxInfo) bool, error) {
+	special, err := b.DetectSpecialFolders()
+	if err != nil {
+		return nil, err
+	}
+
+	names := map[Folder]bool{special.Trash: true, special.Junk: true, special.Sent: true, special.Drafts: true, special.Archive: true}
+	return func(mbox *imap.MailboxInfo) bool {
+		return strings.EqualFold(mbox.Name, imap.InboxName) || names[Folder(mbox.Name)] || isSpecialUse(mbox)
+	}, nil
+}
+
+// isSpecialUse reports whether the mailbox carries a SPECIAL-USE attribute.
+func isSpecialUse(mbox *imap.MailboxInfo) bool {
+	for _, attr := range specialUseAttrs {
+		if hasAttr(mbox, attr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// folderDepth returns the hierarchy level of the mailbox, 0 for top-level ones.
+func folderDepth(mbox *imap.MailboxInfo) int {
+	if mbox.Delimiter == "" {
+		return 0
+	}
+
+	return strings.Count(mbox.Name, mbox.Delimiter)
+}
+
+// hasChildren reports whether any of the remaining mailboxes is below mbox.
+func hasChildren(mbox *imap.MailboxInfo, remaining map[string]bool) bool {
+	if mbox.Delimiter == "" {
+		return false
+	}
+
+	prefix := mbox.Name + mbox.Delimiter
+	for name := range remaining {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,123 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// seqTracker maps the sequence numbers of EXPUNGE responses back to the sequence numbers the messages had before
+// the expunge began. Every response shifts the sequence numbers of all following messages down by one.
+// It is a Fenwick tree over the original positions, holding 1 for every message not expunged yet.
+type seqTracker struct {
+	tree []int32
+}
+
+// newSeqTracker tracks a mailbox with n messages.
+func newSeqTracker(n uint32) *seqTracker {
+	tree := make([]int32, n+1)
+	for i := 1; i <= int(n); i++ {
+		tree[i] = int32(i & -i)
+	}
+
+	return &seqTracker{tree: tree}
+}
+
+// expunge removes the message with the current sequence number seq and returns its original sequence number,
+// 0 for sequence numbers beyond the tracked messages.
+func (t *seqTracker) expunge(seq uint32) uint32 {
+	n := len(t.tree) - 1
+	if seq == 0 || n == 0 {
+		return 0
+	}
+
+	// Find the smallest position with seq remaining messages up to it.
+	pos, rem := 0, int32(seq)
+	for step := 1 << (bits.Len(uint(n)) - 1); step > 0; step >>= 1 {
+		if pos+step <= n && t.tree[pos+step] < rem {
+			pos += step
+			rem -= t.tree[pos]
+		}
+	}
+
+	orig := pos + 1
+	if orig > n {
+		return 0
+	}
+
+	for i := orig; i <= n; i += i & -i {
+		t.tree[i]--
+	}
+
+	return uint32(orig)
+}
+
+const (
+	// expungeAttempts is how often an EXPUNGE failing with a transient error is tried.
+	expungeAttempts = 3
+	// expungeDelay is the wait before the first retry of an EXPUNGE, every further one waits twice as long.
+	expungeDelay = 500 * time.Millisecond
+)
+
+// transientExpungeTexts are parts of the responses servers refuse an EXPUNGE with while another client holds a
+// lock on the mailbox.
+var transientExpungeTexts = []string{
+	"lock",
+	"in use",
+	"inuse",
+	"try again",
+	"temporarily",
+	"unavailable",
+}
+
+// ErrNotExpunged is returned when the EXPUNGE still failed after its retries. The messages are flagged as
+// deleted but still in the folder, a later EXPUNGE, e.g. by another client, removes them.
+type ErrNotExpunged struct {
+	// Flagged are the UIDs of the messages flagged as deleted.
+	Flagged string
+	Err     error
+}
+
+func (e ErrNotExpunged) Error() string {
+	return fmt.Sprintf("inbox: messages %s flagged as deleted but not expunged: %v", e.Flagged, e.Err)
+}
+
+func (e ErrNotExpunged) Unwrap() error {
+	return e.Err
+}
+
+// isTransientExpunge reports whether the EXPUNGE failed because of a temporary lock of the mailbox.
+func isTransientExpunge(err error) bool {
+	text := strings.ToLower(err.Error())
+	for _, t := range transientExpungeTexts {
+		if strings.Contains(text, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// retryExpunge runs expunge, retrying it with backoff while it fails with a transient error. The STORE of the
+// deleted flags already succeeded, so only the EXPUNGE is repeated.
+func retryExpunge(b *Inbox, delUIDs *imap.SeqSet, expunge func() error) error {
+	delay := expungeDelay
+	for attempt := 1; ; attempt++ {
+		err := expunge()
+		if err == nil {
+			return nil
+		}
+
+		if !isTransientExpunge(err) || attempt >= expungeAttempts || checkOpen(b) != nil {
+			return ErrNotExpunged{Flagged: delUIDs.String(), Err: err}
+		}
+
+		log.Println("Expunge failed:", err, "- retrying in", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
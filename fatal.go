@@ -0,0 +1,36 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/emersion/go-imap/client"
+)
+
+// isFatal reports whether err leaves the connection unusable, so continuing with further folders or rules is
+// pointless: the Inbox was closed, the login was lost or refused, or the connection broke. A broken connection
+// isn't fatal with WithAutoReconnect, the next unit logs in again.
+// Multi-folder and multi-rule runs abort on fatal errors. Errors of a single unit, like a missing or read-only
+// folder or criteria the server can't search, are collected while the run continues with the next unit; the run
+// returns the results of all units, failed ones carrying their error, and errors.Join of the unit errors.
+func isFatal(b *Inbox, err error) bool {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.Is(err, ErrClosed), errors.Is(err, ErrNotAuthenticated), errors.Is(err, ErrAuthentication),
+		errors.Is(err, client.ErrNotLoggedIn):
+		return true
+	}
+
+	if b.closed.Load() {
+		return true
+	}
+
+	var netErr *net.OpError
+	lost := errors.Is(err, ErrConnectionLost) || errors.Is(err, client.ErrAlreadyLoggedOut) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.As(err, &netErr) || loggedOut(currentClient(b))
+	return lost && !b.autoReconnect
+}
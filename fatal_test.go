@@ -0,0 +1,193 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/client"
+)
+
+func TestIsFatal(t *testing.T) {
+	s := newTestServer(t)
+	b := s.dial(t)
+
+	connection := []error{
+		ErrConnectionLost,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+		net.ErrClosed,
+		&net.OpError{Op: "read", Err: errors.New("connection reset by peer")},
+		fmt.Errorf("inbox: fetching Archive: %w", io.EOF),
+	}
+	session := []error{
+		context.Canceled,
+		fmt.Errorf("fetch: %w", context.DeadlineExceeded),
+		ErrClosed,
+		ErrNotAuthenticated,
+		fmt.Errorf("%w: bad password", ErrAuthentication),
+		client.ErrNotLoggedIn,
+	}
+	unit := []error{
+		errors.New("No such mailbox"),
+		fmt.Errorf("inbox: %w", ErrFolderNotFound),
+		ErrCapabilityMissing{Capability: "MOVE"},
+		ErrBudgetExhausted,
+		ErrVerificationFailed{},
+	}
+
+	for _, err := range append(connection, session...) {
+		if !isFatal(b, err) {
+			t.Errorf("isFatal(%v) = false, want true", err)
+		}
+	}
+	for _, err := range unit {
+		if isFatal(b, err) {
+			t.Errorf("isFatal(%v) = true, want false", err)
+		}
+	}
+
+	// With WithAutoReconnect, the next unit logs in again after a broken connection.
+	b.autoReconnect = true
+	for _, err := range connection {
+		if isFatal(b, err) {
+			t.Errorf("isFatal(%v) with auto reconnect = true, want false", err)
+		}
+	}
+	for _, err := range session {
+		if !isFatal(b, err) {
+			t.Errorf("isFatal(%v) with auto reconnect = false, want true", err)
+		}
+	}
+	b.autoReconnect = false
+
+	if err := b.Logout(); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range unit {
+		if !isFatal(b, err) {
+			t.Errorf("isFatal(%v) after logout = false, want true", err)
+		}
+	}
+}
+
+// A folder error is collected while the run continues with the next folder.
+func TestMultiFolderRunContinuesAfterUnitError(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	b := s.dial(t)
+
+	results, err := b.DeleteAllMessagesInFolders(true, "Missing", "Archive")
+	if err == nil || !strings.Contains(err.Error(), "Missing") {
+		t.Errorf("error = %v, want the error of Missing", err)
+	}
+	if res, ok := results["Archive"]; !ok || res.Deleted != 1 || res.Err != nil {
+		t.Errorf("result of Archive = %+v, want one deleted message", res)
+	}
+	if res, ok := results["Missing"]; !ok || res.Err == nil {
+		t.Errorf("result of Missing = %+v, want its error", res)
+	}
+}
+
+// brokenBackend lists the selectable mailbox name, which fails to open like a folder another client just deleted.
+// Mailboxes ending in "Stuck" can't be deleted.
+type brokenBackend struct {
+	backend.Backend
+	name string
+}
+
+func (be brokenBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := be.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return brokenUser{User: u, name: be.name}, nil
+}
+
+type brokenUser struct {
+	backend.User
+	name string
+}
+
+func (u brokenUser) ListMailboxes(subscribed bool) ([]backend.Mailbox, error) {
+	mailboxes, err := u.User.ListMailboxes(subscribed)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]backend.Mailbox{brokenMailbox{name: u.name}}, mailboxes...), nil
+}
+
+func (u brokenUser) GetMailbox(name string) (backend.Mailbox, error) {
+	if name == u.name {
+		return nil, errors.New("Mailbox is gone")
+	}
+
+	return u.User.GetMailbox(name)
+}
+
+func (u brokenUser) DeleteMailbox(name string) error {
+	if strings.HasSuffix(name, "Stuck") {
+		return errors.New("Mailbox is in use")
+	}
+
+	return u.User.DeleteMailbox(name)
+}
+
+type brokenMailbox struct {
+	backend.Mailbox
+	name string
+}
+
+func (m brokenMailbox) Name() string { return m.name }
+
+func (m brokenMailbox) Info() (*imap.MailboxInfo, error) {
+	return &imap.MailboxInfo{Delimiter: "/", Name: m.name}, nil
+}
+
+func TestUnitErrorsContinue(t *testing.T) {
+	s := newTestServer(t, func(be backend.Backend) backend.Backend { return brokenBackend{Backend: be, name: "Tickets/Broken"} })
+	s.addMessage(t, "Tickets/Good", "a@example.com", "ticket", time.Now())
+	s.mailbox(t, "Empty")
+	s.mailbox(t, "Deep/Stuck")
+	b := s.dial(t)
+
+	counts, err := b.SenderFolderCounts("a@example.com")
+	if err == nil || counts["Tickets/Good"] != 1 {
+		t.Errorf("SenderFolderCounts = %v, err %v, want Tickets/Good counted and the error of Tickets/Broken", counts, err)
+	}
+
+	largest, err := b.LargestMessages(1)
+	if err == nil || len(largest) != 1 {
+		t.Errorf("LargestMessages = %d summaries, err %v, want one and the error of Tickets/Broken", len(largest), err)
+	}
+
+	results, err := b.CleanFoldersMatching("Tickets/*", EmptyOnly)
+	if err == nil {
+		t.Error("CleanFoldersMatching succeeded, want the error of Tickets/Broken")
+	}
+	failed := false
+	for _, res := range results {
+		failed = failed || res.Folder == "Tickets/Broken" && res.Err != nil
+	}
+	if !failed {
+		t.Errorf("CleanFoldersMatching results %+v, want Tickets/Broken with its error", results)
+	}
+	if n := len(s.mailbox(t, "Tickets/Good").Messages); n != 0 {
+		t.Errorf("Tickets/Good holds %d messages after CleanFoldersMatching, want 0", n)
+	}
+
+	deleted, err := b.DeleteEmptyFolders(nil)
+	if err == nil || !slices.Contains(deleted, "Empty") {
+		t.Errorf("DeleteEmptyFolders = %v, err %v, want Empty deleted and the error of Deep/Stuck", deleted, err)
+	}
+}
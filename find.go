@@ -0,0 +1,178 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/responses"
+)
+
+// Find returns the UIDs of all messages in the folder matching crit. The folder is examined read-only
+// and bodies are never fetched, so Find has no side effects. The result can be reused with criteria.ByUIDs.
+func (b *Inbox) Find(folder Folder, crit criteria.Criteria) ([]uint32, error) {
+	defer operation(b)()
+	if _, err := examineFolder(b, folder); err != nil {
+		return nil, err
+	}
+
+	return matchingUIDs(b, crit)
+}
+
+// FindSummaries returns the summaries of all messages in the folder matching crit, without side effects.
+func (b *Inbox) FindSummaries(folder Folder, crit criteria.Criteria) ([]MessageSummary, error) {
+	defer operation(b)()
+	if _, err := examineFolder(b, folder); err != nil {
+		return nil, err
+	}
+
+	var summaries []MessageSummary
+	err := findMessages(b, crit, summaryFetchItems(b), func(msg *imap.Message) bool {
+		summaries = append(summaries, newSummary(folder, msg))
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries, addSnippets(b, summaries)
+}
+
+// matchingUIDs returns the UIDs of all messages in the selected folder matching crit.
+// Messages are only fetched when the server can't decide crit on its own.
+func matchingUIDs(b *Inbox, crit criteria.Criteria) ([]uint32, error) {
+	if crit.Exact() {
+		return searchUIDs(b, crit)
+	}
+
+	var uids []uint32
+	err := findMessages(b, crit, nil, func(msg *imap.Message) bool {
+		uids = append(uids, msg.Uid)
+		return true
+	})
+
+	return uids, err
+}
+
+// Count returns the number of messages in the folder matching crit. Criteria the server can decide on its own are
+// counted with a single SEARCH (ESEARCH COUNT when advertised), otherwise only the fields crit needs are fetched.
+// Counting all messages uses STATUS without selecting the folder.
+func (b *Inbox) Count(folder Folder, crit criteria.Criteria) (int, error) {
+	defer operation(b)()
+	if crit == criteria.All() {
+		return b.MessageCount(folder)
+	}
+
+	if _, err := examineFolder(b, folder); err != nil {
+		return 0, err
+	}
+
+	if crit.Exact() {
+		if supports(b, "ESEARCH") {
+			return esearchCount(b, crit)
+		}
+	}
+
+	uids, err := matchingUIDs(b, crit)
+	return len(uids), err
+}
+
+// esearchCount counts the candidates for crit with SEARCH RETURN (COUNT) of RFC 4731.
+func esearchCount(b *Inbox, crit criteria.Criteria) (int, error) {
+	if err := requireCriteria(b, crit); err != nil {
+		return 0, err
+	}
+
+	if criteria.MatchesNothing(crit) {
+		return 0, nil
+	}
+
+	cmd := &imap.Command{
+		Name:      "SEARCH",
+		Arguments: append([]interface{}{imap.RawString("RETURN"), []interface{}{imap.RawString("COUNT")}}, searchKeys(crit)...),
+	}
+
+	count := 0
+	handler := responses.HandlerFunc(func(resp imap.Resp) error {
+		name, fields, ok := imap.ParseNamedResp(resp)
+		if !ok || name != "ESEARCH" {
+			return responses.ErrUnhandled
+		}
+
+		for i := 0; i+1 < len(fields); i++ {
+			if key, ok := fields[i].(string); ok && strings.EqualFold(key, "COUNT") {
+				n, err := imap.ParseNumber(fields[i+1])
+				if err != nil {
+					return err
+				}
+				count = int(n)
+			}
+		}
+
+		return nil
+	})
+
+	status, err := conn(b).Execute(cmd, handler)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, status.Err()
+}
+
+// FindSenderFolders returns the folders holding at least one message from addr, which may also be a domain
+// pattern. Every selectable folder is examined read-only. Failing folders don't stop the search unless the error
+// is fatal, see isFatal; the folders found are returned with errors.Join of the folder errors.
+func (b *Inbox) FindSenderFolders(addr string) ([]Folder, error) {
+	defer operation(b)()
+	counts, err := b.SenderFolderCounts(addr)
+	folders := make([]Folder, 0, len(counts))
+	for folder := range counts {
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i] < folders[j] })
+
+	return folders, err
+}
+
+// SenderFolderCounts is like FindSenderFolders, but also returns the number of messages from addr per folder.
+func (b *Inbox) SenderFolderCounts(addr string) (map[Folder]int, error) {
+	defer operation(b)()
+	addrs, err := normalizeAddresses([]string{addr})
+	if err != nil {
+		return nil, err
+	}
+
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	crit := b.matcher.FromAny(addrs...)
+	counts := make(map[Folder]int)
+	var errs []error
+	for _, mbox := range mailboxes {
+		if !selectable(mbox) {
+			continue
+		}
+
+		folder := logicalFolder(b, mbox.Name)
+		n, err := b.Count(folder, crit)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				break
+			}
+			continue
+		}
+
+		if n > 0 {
+			counts[folder] = n
+		}
+	}
+
+	return counts, errors.Join(errs...)
+}
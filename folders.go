@@ -0,0 +1,317 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// WithConfirmToken confirms account-wide operations like CleanAccount. The token must be the account username.
+func WithConfirmToken(token string) Option {
+	return func(i *Inbox) {
+		i.token = token
+	}
+}
+
+// ListFolders returns all folders of the account, including \Noselect containers. Use ListFolderInfos to tell
+// them apart.
+func (b *Inbox) ListFolders() ([]Folder, error) {
+	defer operation(b)()
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	folders := make([]Folder, 0, len(mailboxes))
+	for _, mbox := range mailboxes {
+		folders = append(folders, logicalFolder(b, mbox.Name))
+	}
+
+	return folders, nil
+}
+
+// FolderInfo describes a folder as listed by the server.
+type FolderInfo struct {
+	Folder Folder
+	// Attributes are the LIST attributes, like \Noselect or \Trash.
+	Attributes []string
+	// Delimiter is the hierarchy delimiter of the server, empty for flat servers.
+	Delimiter string
+}
+
+// Selectable reports whether the folder can be selected. \Noselect folders only contain other folders.
+func (f FolderInfo) Selectable() bool {
+	return selectable(&imap.MailboxInfo{Attributes: f.Attributes})
+}
+
+// ListFolderInfos returns all folders of the account with their attributes.
+func (b *Inbox) ListFolderInfos() ([]FolderInfo, error) {
+	defer operation(b)()
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]FolderInfo, 0, len(mailboxes))
+	for _, mbox := range mailboxes {
+		infos = append(infos, FolderInfo{Folder: logicalFolder(b, mbox.Name), Attributes: mbox.Attributes, Delimiter: mbox.Delimiter})
+	}
+
+	return infos, nil
+}
+
+// ExpandFolders returns the selectable folders matching pattern. "*" matches any part of a name including the
+// hierarchy delimiter, "%" stops at it, so "Lists/*" returns all folders below "Lists". Patterns are written with
+// "/" and translated to the delimiter of the server, like "." on many Courier and Cyrus servers.
+func (b *Inbox) ExpandFolders(pattern string) ([]Folder, error) {
+	defer operation(b)()
+	if b.namespace.known {
+		pattern = b.namespace.serverName(pattern)
+	} else {
+		delim, err := hierarchyDelimiter(b)
+		if err != nil {
+			return nil, err
+		}
+
+		if delim != "" && delim != "/" {
+			pattern = strings.ReplaceAll(pattern, "/", delim)
+		}
+	}
+
+	mailboxes, err := listPattern(b, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []Folder
+	for _, mbox := range mailboxes {
+		if selectable(mbox) {
+			folders = append(folders, logicalFolder(b, mbox.Name))
+		}
+	}
+
+	return folders, nil
+}
+
+// hierarchyDelimiter returns the hierarchy delimiter of the server, empty for flat servers.
+// LIST with an empty pattern only returns the delimiter (RFC 3501 6.3.8).
+func hierarchyDelimiter(b *Inbox) (string, error) {
+	mailboxes, err := listPattern(b, "")
+	if err != nil || len(mailboxes) == 0 {
+		return "", err
+	}
+
+	return mailboxes[0].Delimiter, nil
+}
+
+// AccountResult is the outcome of CleanAccount.
+type AccountResult struct {
+	// Folders holds the result of every cleaned folder.
+	Folders map[Folder]DeleteResult
+	// Matched and Deleted are the totals over all folders.
+	Matched int
+	Deleted int
+}
+
+// CleanAccount empties every selectable folder of the account except the excluded ones.
+// Exclusions are matched case-sensitively, except INBOX. Because the whole account is affected,
+// it refuses to run unless WithConfirmToken was given the account username.
+func (b *Inbox) CleanAccount(expunge bool, exclude []Folder) (AccountResult, error) {
+	defer operation(b)()
+	var res AccountResult
+	if b.token == "" || b.token != b.cred.Username {
+		return res, fmt.Errorf("%w: cleaning the account needs WithConfirmToken(<username>)", ErrConfirmationRequired)
+	}
+
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return res, err
+	}
+
+	var folders []Folder
+	for _, mbox := range mailboxes {
+		if !selectable(mbox) || folderExcluded(string(logicalFolder(b, mbox.Name)), exclude) {
+			continue
+		}
+
+		folders = append(folders, logicalFolder(b, mbox.Name))
+	}
+
+	res.Folders, err = forEachFolder(b, folders, func(folder Folder) (DeleteResult, error) {
+		return deleteAllMessagesInFolder(b, expunge, folder)
+	})
+
+	for _, folderRes := range res.Folders {
+		res.Matched += folderRes.Matched
+		res.Deleted += folderRes.Deleted
+	}
+	log.Println("Cleaned", len(res.Folders), "folders:", res.Matched, "messages matched,", res.Deleted, "deleted")
+
+	return res, err
+}
+
+// listMailboxes lists all mailboxes of the account.
+func listMailboxes(b *Inbox) ([]*imap.MailboxInfo, error) {
+	return listPattern(b, "*")
+}
+
+// listPattern lists the mailboxes matching the LIST pattern.
+func listPattern(b *Inbox, pattern string) ([]*imap.MailboxInfo, error) {
+	if err := checkOpen(b); err != nil {
+		return nil, err
+	}
+
+	errChan := make(chan error, 1)
+	mailboxes := make(chan *imap.MailboxInfo, 10)
+	go func() {
+		errChan <- conn(b).List("", pattern, mailboxes)
+	}()
+
+	var infos []*imap.MailboxInfo
+	for mbox := range mailboxes {
+		infos = append(infos, mbox)
+	}
+
+	return infos, <-errChan
+}
+
+// nonExistentAttr marks folders which only exist as part of the hierarchy (RFC 5258).
+const nonExistentAttr = "\\NonExistent"
+
+// selectable reports whether the mailbox can be selected, which \Noselect and \NonExistent ones can't.
+func selectable(mbox *imap.MailboxInfo) bool {
+	return !hasAttr(mbox, imap.NoSelectAttr) && !hasAttr(mbox, nonExistentAttr)
+}
+
+// hasAttr reports whether the mailbox carries the given attribute.
+func hasAttr(mbox *imap.MailboxInfo, attr string) bool {
+	for _, a := range mbox.Attributes {
+		if strings.EqualFold(a, attr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// folderExcluded reports whether name is one of the excluded folders.
+func folderExcluded(name string, exclude []Folder) bool {
+	for _, ex := range exclude {
+		if string(ex) == name {
+			return true
+		}
+
+		if strings.EqualFold(string(ex), imap.InboxName) && strings.EqualFold(name, imap.InboxName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SpecialFolders holds the folders with a special use on the connected account.
+// A field is empty when no such folder was found.
+type SpecialFolders struct {
+	Trash   Folder
+	Junk    Folder
+	Sent    Folder
+	Drafts  Folder
+	Archive Folder
+}
+
+// specialFolderNames are the well-known folder names used when the server doesn't advertise SPECIAL-USE attributes.
+var specialFolderNames = map[string][]string{
+	imap.TrashAttr:   {"Trash", "Deleted Items", "Deleted Messages", "Papierkorb", "Gelöscht"},
+	imap.JunkAttr:    {"Junk", "Spam", "Spamverdacht", "Junk E-Mail", "Bulk Mail"},
+	imap.SentAttr:    {"Sent", "Sent Items", "Sent Messages", "Gesendet", "Gesendete Objekte"},
+	imap.DraftsAttr:  {"Drafts", "Entwürfe"},
+	imap.ArchiveAttr: {"Archive", "Archiv"},
+}
+
+// providerFolderNames are the localized folder names of a provider, tried before specialFolderNames.
+var providerFolderNames = map[ImapProvider]map[string][]string{
+	GMX: {
+		imap.TrashAttr:  {"Papierkorb", "Trash"},
+		imap.JunkAttr:   {"Spamverdacht", "Spam"},
+		imap.SentAttr:   {"Gesendet", "Sent"},
+		imap.DraftsAttr: {"Entwürfe", "Drafts"},
+	},
+	Gmail: {
+		imap.TrashAttr:  {"Trash", "Bin", "Papierkorb"},
+		imap.JunkAttr:   {"Spam"},
+		imap.SentAttr:   {"Sent Mail", "Gesendet"},
+		imap.DraftsAttr: {"Drafts", "Entwürfe"},
+	},
+}
+
+// DetectSpecialFolders resolves the trash, junk, sent, drafts and archive folders of the account.
+// SPECIAL-USE attributes (RFC 6154) are preferred, well-known folder names are used as fallback.
+func (b *Inbox) DetectSpecialFolders() (SpecialFolders, error) {
+	defer operation(b)()
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return SpecialFolders{}, err
+	}
+
+	return SpecialFolders{
+		Trash:   specialFolder(mailboxes, imap.TrashAttr, specialFolderCandidates(b, imap.TrashAttr)),
+		Junk:    specialFolder(mailboxes, imap.JunkAttr, specialFolderCandidates(b, imap.JunkAttr)),
+		Sent:    specialFolder(mailboxes, imap.SentAttr, specialFolderCandidates(b, imap.SentAttr)),
+		Drafts:  specialFolder(mailboxes, imap.DraftsAttr, specialFolderCandidates(b, imap.DraftsAttr)),
+		Archive: specialFolder(mailboxes, imap.ArchiveAttr, specialFolderCandidates(b, imap.ArchiveAttr)),
+	}, nil
+}
+
+// specialFolderCandidates returns the folder names tried for the special-use attribute, the provider's first.
+func specialFolderCandidates(b *Inbox, attr string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range append(providerFolderNames[b.provider][attr], specialFolderNames[attr]...) {
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// specialFolder returns the folder with the given special-use attribute, falling back to the candidate names.
+func specialFolder(mailboxes []*imap.MailboxInfo, attr string, candidates []string) Folder {
+	for _, mbox := range mailboxes {
+		if hasAttr(mbox, attr) {
+			return Folder(mbox.Name)
+		}
+	}
+
+	for _, name := range candidates {
+		for _, mbox := range mailboxes {
+			if !selectable(mbox) {
+				continue
+			}
+
+			if strings.EqualFold(leafName(mbox), name) {
+				return Folder(mbox.Name)
+			}
+		}
+	}
+
+	return ""
+}
+
+// leafName returns the last hierarchy level of the mailbox name.
+func leafName(mbox *imap.MailboxInfo) string {
+	if mbox.Delimiter == "" {
+		return mbox.Name
+	}
+
+	parts := strings.Split(mbox.Name, mbox.Delimiter)
+	return parts[len(parts)-1]
+}
+
+// trashFolder returns the detected trash folder of the account.
+func trashFolder(b *Inbox) (Folder, error) {
+	return b.ResolveFolder(WellKnownTrash)
+}
@@ -0,0 +1,108 @@
+package inbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+)
+
+// noselectBackend lists the container "Lists" as \Noselect parent of the mailboxes below it. Selecting it fails
+// like on servers which create such containers implicitly.
+type noselectBackend struct {
+	backend.Backend
+}
+
+func (be noselectBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	u, err := be.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return noselectUser{u}, nil
+}
+
+type noselectUser struct {
+	backend.User
+}
+
+func (u noselectUser) ListMailboxes(subscribed bool) ([]backend.Mailbox, error) {
+	mailboxes, err := u.User.ListMailboxes(subscribed)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(mailboxes, noselectMailbox{}), nil
+}
+
+func (u noselectUser) GetMailbox(name string) (backend.Mailbox, error) {
+	if name == "Lists" {
+		return nil, errors.New("Mailbox is not selectable")
+	}
+
+	return u.User.GetMailbox(name)
+}
+
+type noselectMailbox struct {
+	backend.Mailbox
+}
+
+func (noselectMailbox) Name() string { return "Lists" }
+
+func (noselectMailbox) Info() (*imap.MailboxInfo, error) {
+	return &imap.MailboxInfo{Attributes: []string{imap.NoSelectAttr}, Delimiter: "/", Name: "Lists"}, nil
+}
+
+func TestNoselectParent(t *testing.T) {
+	s := newTestServer(t, func(be backend.Backend) backend.Backend { return noselectBackend{be} })
+	s.addMessage(t, "Lists/Go", "golang-nuts@example.com", "release", time.Now())
+	s.addMessage(t, "Lists/Rust", "rust@example.com", "edition", time.Now())
+	b := s.dial(t, WithConfirmToken("username"))
+
+	infos, err := b.ListFolderInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, info := range infos {
+		if info.Folder == "Lists" {
+			found = true
+			if info.Selectable() {
+				t.Errorf("Lists with attributes %v is selectable", info.Attributes)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("ListFolderInfos = %v, missing the container Lists", infos)
+	}
+
+	folders, err := b.ExpandFolders("*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, folder := range folders {
+		if folder == "Lists" {
+			t.Errorf("ExpandFolders(*) = %v, includes the container Lists", folders)
+		}
+	}
+
+	// The memory backend starts with a message in INBOX.
+	want := len(s.mailbox(t, "INBOX").Messages) + 2
+	res, err := b.CleanAccount(true, nil)
+	if err != nil {
+		t.Fatalf("CleanAccount: %v", err)
+	}
+	if _, ok := res.Folders["Lists"]; ok {
+		t.Error("CleanAccount walked into the container Lists")
+	}
+	if res.Matched != want || res.Deleted != want {
+		t.Errorf("CleanAccount totals: Matched %d, Deleted %d, want %d each", res.Matched, res.Deleted, want)
+	}
+	for _, name := range []string{"Lists/Go", "Lists/Rust"} {
+		if n := len(s.mailbox(t, name).Messages); n != 0 {
+			t.Errorf("%s holds %d messages after CleanAccount, want 0", name, n)
+		}
+	}
+}
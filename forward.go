@@ -0,0 +1,154 @@
+package inbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// SMTPConfig describes the SMTP server used to forward messages.
+type SMTPConfig struct {
+	// Addr is the host:port of the SMTP server.
+	Addr     string
+	Username string
+	Password string
+	// From is the envelope and header sender of forwarded messages.
+	From string
+}
+
+// String redacts the SMTP credentials, so printing the config by accident doesn't leak them.
+func (c SMTPConfig) String() string {
+	return fmt.Sprintf("SMTPConfig{Addr: %s, Username: %s, Password: %s, From: %s}", c.Addr, redactedText, redactedText, c.From)
+}
+
+// GoString redacts the SMTP credentials for %#v.
+func (c SMTPConfig) GoString() string {
+	return c.String()
+}
+
+type forwarder struct {
+	smtp *SMTPConfig
+	to   string
+}
+
+// WithForwardBeforeDelete forwards every message to the given address as an attached message/rfc822
+// before it gets deleted. A message is only deleted when the SMTP server accepted its forward.
+func WithForwardBeforeDelete(cfg *SMTPConfig, to string) Option {
+	return func(i *Inbox) {
+		i.forward = &forwarder{smtp: cfg, to: to}
+	}
+}
+
+// forwardMessages forwards all messages in uidSet and returns the UIDs of the messages forwarded successfully.
+// Failed forwards, including messages whose body couldn't be fetched, are reported individually in the returned
+// error and are not part of the returned UIDs, so they are kept.
+func forwardMessages(b *Inbox, uidSet *imap.SeqSet) (*imap.SeqSet, error) {
+	section := &imap.BodySectionName{Peek: true}
+	messages := make(chan *imap.Message, 10)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- conn(b).UidFetch(uidSet, []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope, section.FetchItem()}, messages)
+	}()
+
+	type rawMessage struct {
+		uid     uint32
+		subject string
+		body    []byte
+	}
+
+	var raws []rawMessage
+	var errs []error
+	for msg := range messages {
+		body := msg.GetBody(section)
+		if body == nil {
+			errs = append(errs, fmt.Errorf("forward message %d: server returned no body", msg.Uid))
+			continue
+		}
+
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("forward message %d: reading body: %w", msg.Uid, err))
+			continue
+		}
+
+		subject := ""
+		if msg.Envelope != nil {
+			subject = criteria.DecodeHeader(msg.Envelope.Subject)
+		}
+
+		raws = append(raws, rawMessage{uid: msg.Uid, subject: subject, body: raw})
+	}
+
+	if err := <-errChan; err != nil {
+		return new(imap.SeqSet), errors.Join(append(errs, err)...)
+	}
+
+	forwarded := new(imap.SeqSet)
+	for _, raw := range raws {
+		if err := b.forward.send(raw.subject, raw.body); err != nil {
+			log.Println("Forwarding message", raw.uid, "failed:", err)
+			errs = append(errs, fmt.Errorf("forward message %d: %w", raw.uid, err))
+			continue
+		}
+
+		forwarded.AddNum(raw.uid)
+	}
+
+	return forwarded, errors.Join(errs...)
+}
+
+// send forwards a single raw message. smtp.SendMail only returns nil when the server answered 250 to the data.
+func (f *forwarder) send(subject string, raw []byte) error {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", f.smtp.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", f.to)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Fwd: "+subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(text, "Forwarded before deletion.\r\n")
+
+	attachment, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":        {"message/rfc822"},
+		"Content-Disposition": {"attachment"},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := attachment.Write(raw); err != nil {
+		return err
+	}
+
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if f.smtp.Username != "" {
+		host, _, err := net.SplitHostPort(f.smtp.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", f.smtp.Username, f.smtp.Password, host)
+	}
+
+	return smtp.SendMail(f.smtp.Addr, auth, f.smtp.From, []string{f.to}, buf.Bytes())
+}
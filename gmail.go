@@ -0,0 +1,41 @@
+package inbox
+
+import (
+	"log"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// RemoveGmailLabel removes the Gmail label from all messages in the folder matching crit and returns the number of
+// changed messages. On Gmail this is how a message gets archived: deleting it from a label folder would move it to
+// the trash. Servers without X-GM-EXT-1 return ErrCapabilityMissing.
+func (b *Inbox) RemoveGmailLabel(folder Folder, label string, crit criteria.Criteria) (int, error) {
+	defer operation(b)()
+	if err := requireCapability(b, criteria.GmailCapability); err != nil {
+		return 0, err
+	}
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return 0, err
+	}
+
+	uids, err := matchingUIDs(b, crit)
+	if err != nil || len(uids) == 0 {
+		return 0, err
+	}
+
+	uidSet := new(imap.SeqSet)
+	uidSet.AddNum(uids...)
+	if skipMutation(b, "remove label", label, "from", uidSet, "in", folder) {
+		return len(uids), nil
+	}
+
+	item := imap.StoreItem("-" + string(criteria.GmailLabelsItem) + ".SILENT")
+	if err := conn(b).UidStore(uidSet, item, []interface{}{label}, nil); err != nil {
+		return 0, err
+	}
+
+	log.Println("Removed label", label, "from", len(uids), "messages in", folder)
+	return len(uids), nil
+}
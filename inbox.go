@@ -1,10 +1,21 @@
 package inbox
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/Batzi1337/go-imapcleaner/criteria"
 	"github.com/emersion/go-imap"
 	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-imap/responses"
+	"golang.org/x/time/rate"
 )
 
 type ImapProvider string
@@ -16,108 +27,470 @@ type Credentials struct {
 	Password string
 }
 
+// String redacts the credentials, so printing them by accident doesn't leak them.
+func (c Credentials) String() string {
+	return "Credentials{Username: " + redactedText + ", Password: " + redactedText + "}"
+}
+
+// GoString redacts the credentials for %#v.
+func (c Credentials) GoString() string {
+	return c.String()
+}
+
 const (
 	GMX           ImapProvider = "imap.gmx.net:993"
+	Gmail         ImapProvider = "imap.gmail.com:993"
 	InboxFolder   Folder       = imap.InboxName
 	GmxSpamFolder Folder       = "Spamverdacht"
 	TrashFolder   Folder       = "Trash"
 )
 
+// ErrClosed is returned by calls on an Inbox which was logged out.
+var ErrClosed = errors.New("inbox: connection closed")
+
+// ErrConfirmationRequired is returned when a destructive operation needs an explicit confirmation.
+var ErrConfirmationRequired = errors.New("inbox: confirmation required")
+
 type Inbox struct {
-	cred   *Credentials
-	client *client.Client
+	// mu guards cred, auth, client, bye and reconnect, which Reauthenticate swaps.
+	mu sync.Mutex
+	// ops keeps Reauthenticate from running while operations do.
+	ops opLock
+
+	provider ImapProvider
+	cred     *Credentials
+	client   *client.Client
+	forward  *forwarder
+	fields   []AddressField
+	confirm  bool
+	token    string
+	metrics  MetricsObserver
+	modSeqs  map[Folder]uint64
+	auth     AuthMechanism
+
+	includeThread bool
+	statePath     string
+
+	rateLimit float64
+	burst     int
+	limiter   *rate.Limiter
+	progress  func(Progress)
+	waited    time.Duration
+
+	loginAttempts int
+	loginDelay    time.Duration
+	tlsConfig     *tls.Config
+
+	checkpoints CheckpointStore
+	matcher     criteria.AddressMatcher
+	cache       *envelopeCache
+	dryRun      bool
+	closed      atomic.Bool
+	auditPath   string
+	resolved    map[Folder]Folder
+	namespace   namespace
+	resume      *ResumeState
+	saveResume  func(*ResumeState) error
+	verify      bool
+
+	snippetChars int
+
+	deadline  time.Time
+	truncated bool
+	tracker   *progressTracker
+
+	preserveFlagged bool
+
+	budget Budget
+	usage  usage
+
+	selected string
+
+	ctx       context.Context
+	reconnect bool
+
+	clock func() time.Time
+
+	autoReconnect bool
+	bye           *byeWatch
+}
+
+// Option configures optional behaviour of an Inbox.
+type Option func(*Inbox)
+
+// WithConfirmDestructive allows DeleteAllMessagesInFolder to expunge the INBOX.
+func WithConfirmDestructive(confirm bool) Option {
+	return func(i *Inbox) {
+		i.confirm = confirm
+	}
 }
 
 // New creates a new Bot and authenticate with the given credentials.
-func New(provider ImapProvider, cred *Credentials) (*Inbox, error) {
+func New(provider ImapProvider, cred *Credentials, opts ...Option) (*Inbox, error) {
 	inbox := new(Inbox)
+	inbox.provider = provider
 	inbox.cred = cred
-
-	// Connect to server
-	client, err := client.DialTLS(string(provider), nil)
-	if err != nil {
-		return nil, err
+	inbox.fields = []AddressField{FromField}
+	inbox.preserveFlagged = true
+	for _, opt := range opts {
+		opt(inbox)
 	}
 
-	err = client.Login(cred.Username, cred.Password)
+	inbox.limiter = newLimiter(inbox.rateLimit, inbox.burst)
+
+	client, err := connect(provider, cred, inbox.auth, inbox)
 	if err != nil {
 		return nil, err
 	}
 
-	inbox.client = client
+	inbox.client, inbox.bye = client, watchBye(client)
+	if err := queryNamespace(inbox); err != nil {
+		log.Println("Querying the namespace failed, using folder names as they are:", err)
+	}
 
 	return inbox, nil
 }
 
+// DeleteResult summarizes a delete operation on a single folder.
+type DeleteResult struct {
+	Folder Folder
+	// Matched is the number of messages selected for deletion.
+	Matched int
+	// Thread is the number of messages added to Matched ones because they belong to the same thread.
+	Thread int
+	// Deleted is the number of messages removed permanently.
+	Deleted int
+	// ExpungedUIDs are the UIDs the server confirmed as expunged, to reconcile with the intended deletions.
+	ExpungedUIDs []uint32
+	// UIDExpunge is true when only the deleted messages were expunged with UID EXPUNGE. Otherwise the server
+	// lacks UIDPLUS and the whole folder was expunged, including messages other clients flagged as deleted.
+	UIDExpunge bool
+	// Unmatched lists the supplied addresses or Message-IDs no message matched.
+	Unmatched []string
+	// Resumed is true when an earlier run of the ResumeState completed or started the folder.
+	Resumed bool
+	// Truncated is true when the deadline of SetDeadline or the budget of WithBudget stopped the search early,
+	// so only part of the folder was processed.
+	Truncated bool
+	// Preserved is the number of messages spared by a bulk delete because they are flagged or important,
+	// see WithPreserveFlagged. They are not counted in Matched.
+	Preserved int
+	// BeforeCount and AfterCount are the message counts of the selected folder before the deletion and after the
+	// expunge, both zero when nothing was expunged or the connection was replaced in between.
+	BeforeCount int
+	AfterCount  int
+	// Warnings report inconsistencies, like counts not adding up because another client changed the folder.
+	Warnings []string
+	// Err is the error of the folder in runs over several folders, nil if it succeeded. The other fields hold
+	// whatever was done before the error.
+	Err error
+}
+
 // DeleteAllMessagesInFolder deletes all messages in the given folder.
-// When expunge is set to "false", no "\DELETED" flag is set (safe mode). When set to "true", all messages removed permenantly.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode) and a summary of what would be deleted is logged.
+// When set to "true", all messages removed permenantly.
+// Expunging the INBOX is refused with ErrConfirmationRequired unless WithConfirmDestructive(true) was given.
 func (i *Inbox) DeleteAllMessagesInFolder(expunge bool, folder Folder) error {
-	mbox, err := selectFolder(i, folder)
-	if err != nil {
+	defer operation(i)()
+	if err := checkInboxConfirmed(i, expunge, folder); err != nil {
 		return err
 	}
 
-	delSeqSet := new(imap.SeqSet)
-	delSeqSet.AddRange(1, mbox.Messages)
+	_, err := deleteAllMessagesInFolder(i, expunge, folder)
+	return err
+}
 
-	if !expunge {
-		return nil
-	}
+// DeleteAllMessagesInFolders deletes all messages in each of the given folders.
+// A failing folder doesn't stop the remaining ones, all errors are joined into the returned error.
+func (i *Inbox) DeleteAllMessagesInFolders(expunge bool, folders ...Folder) (map[Folder]DeleteResult, error) {
+	defer operation(i)()
+	return forEachFolder(i, folders, func(folder Folder) (DeleteResult, error) {
+		if err := checkInboxConfirmed(i, expunge, folder); err != nil {
+			return DeleteResult{Folder: folder}, err
+		}
 
-	return deleteMessagesPermanently(i, delSeqSet)
+		return deleteAllMessagesInFolder(i, expunge, folder)
+	})
 }
 
 // DeleteMessagesInFolderFromAddress sets the "\DELETED" flag to all messages sent from the given addresses.
 // When expunge is set to "false", no "\DELETED" flag is set (safe mode). When set to "true", messages matching to the given
-// addresses are removed permenantly.
+// addresses are removed permenantly. Addresses like "Bob <bob@example.com>" are reduced to the bare address,
+// addresses which can't be parsed return an error.
 func (b *Inbox) DeleteMessagesInFolderFromAddress(expunge bool, folder Folder, addr ...string) error {
+	defer operation(b)()
+	_, err := deleteMessagesInFolderFromAddress(b, expunge, folder, addr)
+	return err
+}
+
+// DeleteMessagesInFoldersFromAddress deletes all messages sent from the given addresses in each of the given folders.
+// A failing folder doesn't stop the remaining ones, all errors are joined into the returned error.
+func (b *Inbox) DeleteMessagesInFoldersFromAddress(expunge bool, folders []Folder, addr ...string) (map[Folder]DeleteResult, error) {
+	defer operation(b)()
+	return forEachFolder(b, folders, func(folder Folder) (DeleteResult, error) {
+		return deleteMessagesInFolderFromAddress(b, expunge, folder, addr)
+	})
+}
+
+// DeleteMessagesInFolderNotFromAddress deletes all messages which were NOT sent from one of the given addresses.
+// This is the inverse of DeleteMessagesInFolderFromAddress, meant for addresses which should only receive mail
+// from a few known senders. When expunge is set to "false", the messages which would be deleted are only listed.
+func (b *Inbox) DeleteMessagesInFolderNotFromAddress(expunge bool, folder Folder, addr ...string) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if len(addr) == 0 {
+		return res, errors.New("inbox: at least one address to keep is required")
+	}
+
+	addr, err = normalizeAddresses(addr)
+	if err != nil {
+		return res, err
+	}
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	log.Println("Messages to delete NOT from", strings.Join(addr, ", ")+":")
+	delUIDs := new(imap.SeqSet)
+	err = findMessages(b, criteria.Not(b.matcher.FromAny(addr...)), summaryFetchItems(b), func(msg *imap.Message) bool {
+		s := newSummary(folder, msg)
+		log.Println("\t", s.FromAddress, s.Subject)
+		delUIDs.AddNum(msg.Uid)
+		res.Matched++
+		return true
+	})
+	if err != nil {
+		return res, err
+	}
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// forEachFolder runs fn for every folder and collects the results, failing folders with their error in Err.
+// Failing folders don't stop the run unless the error is fatal, see isFatal.
+// With WithResumeState, folders completed by an earlier run are skipped. Folders left after the deadline of
+// SetDeadline or the budget of WithBudget are not started and reported as Truncated, a used up budget also
+// returns ErrBudgetExhausted.
+func forEachFolder(b *Inbox, folders []Folder, fn func(Folder) (DeleteResult, error)) (map[Folder]DeleteResult, error) {
+	results := make(map[Folder]DeleteResult, len(folders))
+	var errs []error
+	for _, folder := range folders {
+		if stopEarly(b) {
+			log.Println("Skipping", folder, "after the deadline or budget")
+			results[folder] = DeleteResult{Folder: folder, Truncated: true}
+			continue
+		}
+
+		var res DeleteResult
+		var err error
+		b.truncated = false
+		if b.resume != nil {
+			res, err = resumeFolder(b, folder, fn)
+		} else {
+			res, err = fn(folder)
+		}
+		if res.Folder == "" {
+			res.Folder = folder
+		}
+		res.Truncated = res.Truncated || b.truncated
+		res.Err = err
+		results[folder] = res
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				break
+			}
+		}
+	}
+
+	if budgetExhausted(b) {
+		errs = append(errs, ErrBudgetExhausted)
+	}
+
+	return results, errors.Join(errs...)
+}
+
+// checkInboxConfirmed refuses to expunge the INBOX unless WithConfirmDestructive(true) was given.
+func checkInboxConfirmed(i *Inbox, expunge bool, folder Folder) error {
+	if expunge && !i.confirm && strings.EqualFold(string(folder), imap.InboxName) {
+		return fmt.Errorf("%w: emptying INBOX needs WithConfirmDestructive(true)", ErrConfirmationRequired)
+	}
+
+	return nil
+}
+
+// deleteAllMessagesInFolder deletes the messages present at the time of the UID search. Messages arriving
+// afterwards are kept, messages vanishing in between are not counted, as Deleted comes from the EXPUNGE responses.
+// If nothing needs the individual messages, the folder is emptied without enumerating them, see canEmptyFast.
+func deleteAllMessagesInFolder(i *Inbox, expunge bool, folder Folder) (res DeleteResult, err error) {
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(i, start, &res, err) }(startDelete(i))
+
+	// Empty folders don't need to be selected.
+	if n, err := i.MessageCount(folder); err != nil || n == 0 {
+		return res, err
+	}
+
+	fast := expunge && canEmptyFast(i)
+	if fast {
+		// A fresh SELECT reports the UIDNEXT emptyFolder is bounded by.
+		invalidateSelected(i)
+	}
+	mbox, err := selectFolder(i, folder)
+	if err != nil {
+		return res, err
+	}
+
+	if !expunge {
+		summary, err := summarizeFolder(i, folder, mbox)
+		if err != nil {
+			return res, err
+		}
+		logFolderSummary(summary)
+	}
+
+	if fast && mbox.UidNext > 0 {
+		res.Matched = int(mbox.Messages)
+		if res.Matched == 0 || skipMutation(i, "empty", folder) {
+			return res, nil
+		}
+
+		exp, err := emptyFolder(i, mbox.UidNext)
+		exp.apply(&res)
+		return res, err
+	}
+
+	uids, err := searchUIDs(i, criteria.All())
+	if err != nil {
+		return res, err
+	}
+	uids, res.Preserved, err = preserve(i, uids)
+	if err != nil {
+		return res, err
+	}
+	res.Matched = len(uids)
+	if res.Preserved > 0 {
+		log.Println("Preserving", res.Preserved, "flagged messages in", folder)
+	}
+
+	if !expunge {
+		return res, nil
+	}
+
+	if res.Matched == 0 {
+		return res, nil
+	}
+
+	delUIDs := new(imap.SeqSet)
+	delUIDs.AddNum(uids...)
+	exp, err := deleteMessagesPermanently(i, delUIDs)
+	exp.apply(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+func deleteMessagesInFolderFromAddress(b *Inbox, expunge bool, folder Folder, addr []string) (res DeleteResult, err error) {
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	addr, err = normalizeAddresses(addr)
+	if err != nil {
+		return res, err
+	}
+
 	mbox, err := selectFolder(b, folder)
 	if err != nil {
-		return err
+		return res, err
 	}
 
+	startProgress(b, int(mbox.Messages))
 	errChan := make(chan error, 1)
 	messages := make(chan *imap.Message, mbox.Messages)
 	go func() {
-		errChan <- fetchAllMessages(mbox, b, messages)
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchUid, imap.FetchEnvelope)
 	}()
 
-	delSeqSet := new(imap.SeqSet)
+	delUIDs := new(imap.SeqSet)
 
-	compare(addr, messages, delSeqSet)
+	var msgMap map[string][]string
+	res.Matched, msgMap = compare(b, addr, messages, delUIDs)
+	printMessagesToDelete(msgMap)
+	res.Unmatched = unmatchedAddresses(addr, msgMap)
 
 	if err := <-errChan; err != nil {
-		return err
+		return res, err
 	}
+	observeFetch(b, folder, int(mbox.Messages))
 
-	if !expunge {
-		return nil
+	if err := includeThreads(b, &res, delUIDs); err != nil {
+		return res, err
 	}
 
-	return deleteMessagesPermanently(b, delSeqSet)
-}
-
-// compare adds every message SeqNum sent from one of the given addresses to delSeqSet.
-func compare(address []string, messages chan *imap.Message, delSeqSet *imap.SeqSet) {
-	m := make(chan map[string]string, cap(address))
-	for msg := range messages {
-		go compareMessageWithAddresses(msg, address, m, delSeqSet)
+	if !expunge || res.Matched == 0 {
+		return res, nil
 	}
 
-	close(m)
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	if err != nil {
+		return res, err
+	}
 
-	printMessagesToDelete(m)
+	return res, nil
 }
 
-// printMessagesToDelete lists all messages for each address which will be deleted.
-func printMessagesToDelete(msgMapChan chan map[string]string) {
+// compare adds the UID of every message sent from one of the given addresses to delUIDs.
+// It returns the number of matches and the subjects of the matching messages per address.
+func compare(b *Inbox, address []string, messages chan *imap.Message, delUIDs *imap.SeqSet) (int, map[string][]string) {
 	msgMap := make(map[string][]string)
-	for m := range msgMapChan {
+	matched := 0
+	for msg := range messages {
+		advanceProgress(b, 1)
+		m := compareMessageWithAddresses(b, msg, address)
+		if len(m) == 0 {
+			continue
+		}
+
+		matched++
+		delUIDs.AddNum(msg.Uid)
 		for k := range m {
 			msgMap[k] = append(msgMap[k], m[k])
 		}
 	}
 
+	return matched, msgMap
+}
+
+// unmatchedAddresses returns the addresses without any entry in msgMap.
+func unmatchedAddresses(address []string, msgMap map[string][]string) []string {
+	var unmatched []string
+	for _, addr := range address {
+		if _, ok := msgMap[addr]; !ok {
+			unmatched = append(unmatched, addr)
+		}
+	}
+
+	return unmatched
+}
+
+// printMessagesToDelete lists all messages for each address which will be deleted.
+func printMessagesToDelete(msgMap map[string][]string) {
 	for x := range msgMap {
 		log.Println("Messages to delete from", x+":")
 		for _, y := range msgMap[x] {
@@ -126,57 +499,341 @@ func printMessagesToDelete(msgMapChan chan map[string]string) {
 	}
 }
 
-// deleteMessagesPermanently sets the deleted flag and expunge them.
-func deleteMessagesPermanently(b *Inbox, delSeqSet *imap.SeqSet) error {
-	if err := b.client.Store(delSeqSet, imap.StoreItem(imap.AddFlags), []interface{}{imap.DeletedFlag}, nil); err != nil {
+// expungeResult is the outcome of deleteMessagesPermanently.
+type expungeResult struct {
+	// deleted is the number of messages the server reported as expunged.
+	deleted int
+	// uids are the UIDs of the deleted messages.
+	uids []uint32
+	// uidExpunge is true when only the flagged UIDs were expunged with UID EXPUNGE.
+	uidExpunge bool
+	// flagged is the number of messages the STORE flagged as deleted.
+	flagged int
+	// expunged is the number of EXPUNGE responses, including messages other clients flagged as deleted.
+	expunged int
+	// before and after are the message counts of the folder around the deletion, see snapshot.
+	before   int
+	after    int
+	warnings []string
+}
+
+// apply records the outcome in res.
+func (r expungeResult) apply(res *DeleteResult) {
+	res.Deleted = r.deleted
+	res.ExpungedUIDs = r.uids
+	res.UIDExpunge = r.uidExpunge
+	res.BeforeCount = r.before
+	res.AfterCount = r.after
+	res.Warnings = append(res.Warnings, r.warnings...)
+}
+
+// deleteMessagesPermanently sets the deleted flag on the messages with the given UIDs and expunge them.
+// Without any UIDs, no command is sent at all. Messages are addressed by UID rather than sequence number, as
+// other clients expunging messages between the search and the store shift the sequence numbers of this session.
+// When forwarding is configured, only messages which were forwarded successfully are deleted.
+// The deleted messages are taken from the server's EXPUNGE responses, as messages may vanish between the search
+// and the store. With WithStateFile, messages are deleted in batches and the progress is recorded.
+// With WithVerifyAfterDelete, the folder is searched for the deleted UIDs afterwards.
+func deleteMessagesPermanently(b *Inbox, delUIDs *imap.SeqSet) (expungeResult, error) {
+	if delUIDs == nil || delUIDs.Empty() {
+		// Some servers reject a STORE with an empty set, nothing to do anyway.
+		return expungeResult{}, nil
+	}
+
+	if skipMutation(b, "delete", delUIDs) {
+		return expungeResult{}, nil
+	}
+
+	var forwardErr error
+	if b.forward != nil {
+		delUIDs, forwardErr = forwardMessages(b, delUIDs)
+		if delUIDs.Empty() {
+			return expungeResult{}, forwardErr
+		}
+	}
+
+	entries := auditEntries(b, delUIDs)
+	before, ok := selectedCount(b)
+
+	var res expungeResult
+	var err error
+	if b.statePath != "" {
+		res, err = deleteInBatches(b, delUIDs)
+	} else {
+		res, err = storeAndExpunge(b, delUIDs)
+	}
+	snapshot(b, &res, before, ok)
+	if err == nil && b.verify {
+		err = verifyDeleted(b, delUIDs)
+	}
+
+	writeAudit(b, entries, res.uids)
+	return res, errors.Join(forwardErr, connectionLost(b, err))
+}
+
+// storeAndExpunge flags the messages with the given UIDs as deleted and expunges them. With UIDPLUS only these
+// UIDs are expunged, otherwise EXPUNGE also removes messages other clients flagged as deleted.
+// The STORE responses tell the sequence number of every flagged message, which maps the sequence numbers in the
+// EXPUNGE responses back to UIDs.
+func storeAndExpunge(b *Inbox, delUIDs *imap.SeqSet) (expungeResult, error) {
+	flagged := make(map[uint32]uint32)
+	updates := make(chan *imap.Message, 10)
+	storeErr := make(chan error, 1)
+	go func() {
+		storeErr <- conn(b).UidStore(delUIDs, imap.FormatFlagsOp(imap.AddFlags, false), []interface{}{imap.DeletedFlag}, updates)
+	}()
+	for msg := range updates {
+		flagged[msg.SeqNum] = msg.Uid
+	}
+	if err := <-storeErr; err != nil {
+		return expungeResult{flagged: len(flagged)}, err
+	}
+
+	var tracker *seqTracker
+	if mbox := currentClient(b).Mailbox(); mbox != nil {
+		tracker = newSeqTracker(mbox.Messages)
+	}
+
+	res := expungeResult{uidExpunge: supports(b, "UIDPLUS"), flagged: len(flagged)}
+	err := retryExpunge(b, delUIDs, func() error {
+		return expungeFlagged(b, delUIDs, &res, flagged, tracker)
+	})
+	res.deleted = len(res.uids)
+
+	return res, err
+}
+
+// expungeFlagged expunges the flagged messages once and records the UIDs of the expunged ones in res.
+func expungeFlagged(b *Inbox, delUIDs *imap.SeqSet, res *expungeResult, flagged map[uint32]uint32, tracker *seqTracker) error {
+	defer invalidateSelected(b)
+
+	expunged := make(chan uint32, 10)
+	errChan := make(chan error, 1)
+	go func() {
+		if res.uidExpunge {
+			errChan <- uidExpunge(b, delUIDs, expunged)
+		} else {
+			errChan <- conn(b).Expunge(expunged)
+		}
+	}()
+
+	for seq := range expunged {
+		res.expunged++
+		if tracker == nil {
+			continue
+		}
+		if uid, ok := flagged[tracker.expunge(seq)]; ok {
+			res.uids = append(res.uids, uid)
+		}
+	}
+
+	return <-errChan
+}
+
+// canEmptyFast reports whether a folder can be emptied by emptyFolder, as no option needs to know which messages
+// are deleted: flagged ones aren't preserved, nothing is forwarded, audited, verified or tracked in a state file.
+// Servers not reporting UIDNEXT on SELECT are emptied by enumerating the messages anyway.
+func canEmptyFast(b *Inbox) bool {
+	return !b.preserveFlagged && b.forward == nil && b.auditPath == "" && b.statePath == "" && !b.verify
+}
+
+// emptyFolder flags every message of the selected folder with a UID below uidNext, the UIDNEXT of the select, as
+// deleted with a single silent UID STORE 1:<uidNext-1> and expunges them, without fetching or even searching the
+// messages. Only the number of expunged messages is known. Messages which arrived after the select have greater
+// UIDs and are kept.
+func emptyFolder(b *Inbox, uidNext uint32) (expungeResult, error) {
+	if uidNext <= 1 {
+		return expungeResult{}, nil
+	}
+
+	before, ok := selectedCount(b)
+	all := new(imap.SeqSet)
+	all.AddRange(1, uidNext-1)
+	if err := conn(b).UidStore(all, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.DeletedFlag}, nil); err != nil {
+		return expungeResult{}, err
+	}
+
+	// The silent STORE doesn't tell how many messages it flagged, all of them present before are expected.
+	res := expungeResult{uidExpunge: supports(b, "UIDPLUS"), flagged: before}
+	defer invalidateSelected(b)
+	err := retryExpunge(b, all, func() error {
+		expunged := make(chan uint32, 10)
+		errChan := make(chan error, 1)
+		go func() {
+			if res.uidExpunge {
+				errChan <- uidExpunge(b, all, expunged)
+			} else {
+				errChan <- conn(b).Expunge(expunged)
+			}
+		}()
+
+		for range expunged {
+			res.deleted++
+			res.expunged++
+		}
+
+		return <-errChan
+	})
+
+	snapshot(b, &res, before, ok)
+
+	return res, err
+}
+
+// uidExpunge runs UID EXPUNGE (RFC 4315) for the given UIDs and sends the sequence numbers of the expunged
+// messages to ch, which is closed afterwards.
+func uidExpunge(b *Inbox, uids *imap.SeqSet, ch chan uint32) error {
+	defer close(ch)
+
+	cmd := &imap.Command{Name: "UID", Arguments: []interface{}{imap.RawString("EXPUNGE"), uids}}
+	status, err := conn(b).Execute(cmd, &responses.Expunge{SeqNums: ch})
+	if err != nil {
 		return err
 	}
 
-	return b.client.Expunge(nil)
+	return status.Err()
 }
 
 // selectFolder sets the given folder as selected mailbox.
 func selectFolder(b *Inbox, folder Folder) (*imap.MailboxStatus, error) {
-	mbox, err := b.client.Select(string(folder), false)
+	return openFolder(b, folder, false)
+}
+
+// examineFolder selects the given folder read-only, so no flags can be changed by accident.
+func examineFolder(b *Inbox, folder Folder) (*imap.MailboxStatus, error) {
+	return openFolder(b, folder, true)
+}
+
+// openFolder selects the folder, read-only with EXAMINE if readOnly is set. The SELECT is skipped if the folder
+// is still selected at the same level since the last call, see invalidateSelected.
+func openFolder(b *Inbox, folder Folder, readOnly bool) (*imap.MailboxStatus, error) {
+	if err := checkOpen(b); err != nil {
+		return nil, err
+	}
+
+	folder, err := serverFolder(b, folder)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("Selected folder:", mbox.Name)
+	return selectName(b, string(folder), readOnly)
+}
+
+// selectName selects the mailbox with its name on the server like openFolder.
+func selectName(b *Inbox, name string, readOnly bool) (*imap.MailboxStatus, error) {
+	if mbox := currentClient(b).Mailbox(); mbox != nil && b.selected == name && mbox.Name == b.selected && mbox.ReadOnly == readOnly {
+		return mbox, nil
+	}
+
+	b.selected = ""
+	mbox, err := conn(b).Select(name, readOnly)
+	if err != nil {
+		return nil, connectionLost(b, err)
+	}
+	b.selected = name
+
+	if readOnly {
+		log.Println("Examined folder:", mbox.Name)
+	} else {
+		log.Println("Selected folder:", mbox.Name)
+	}
 
 	return mbox, nil
 }
 
+// invalidateSelected makes the next selectFolder or examineFolder select the folder again. It is called after
+// commands which remove messages or folders, as the message count of the session isn't updated by the EXPUNGE
+// responses the package consumes itself.
+func invalidateSelected(b *Inbox) {
+	b.selected = ""
+}
+
+// Logout logs out and closes the connection. It blocks until the server answered, see LogoutContext.
 func (b *Inbox) Logout() error {
-	return b.client.Logout()
+	return b.LogoutContext(context.Background())
+}
+
+// LogoutContext logs out gracefully, but closes the connection without waiting any longer once ctx is done, e.g.
+// when the server silently dropped it. Afterwards the Inbox is closed and further calls return ErrClosed.
+// The cache file of WithCache is written here.
+func (b *Inbox) LogoutContext(ctx context.Context) error {
+	if b.closed.Swap(true) {
+		return ErrClosed
+	}
+
+	var cacheErr error
+	if b.cache != nil {
+		cacheErr = b.cache.flush()
+	}
+
+	return errors.Join(logoutClient(ctx, currentClient(b)), cacheErr)
 }
 
-// fetchAllMessages fetches all messages in the selected mailbox.
-func fetchAllMessages(mbox *imap.MailboxStatus, b *Inbox, messages chan *imap.Message) error {
-	seqSet := new(imap.SeqSet)
-	seqSet.AddRange(1, mbox.Messages)
-	if err := b.client.Fetch(seqSet, []imap.FetchItem{imap.FetchEnvelope}, messages); err != nil {
+// logoutClient logs the client out, closing the connection without waiting once ctx is done.
+func logoutClient(ctx context.Context, c *client.Client) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Logout()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		c.Terminate()
+		return ctx.Err()
+	}
+}
+
+// checkOpen returns ErrClosed after the Inbox was logged out and ErrConnectionLost after the server closed the
+// connection, unless WithAutoReconnect is set and logging in again succeeds.
+func checkOpen(b *Inbox) error {
+	if b.closed.Load() {
+		return ErrClosed
+	}
+
+	if b.autoReconnect && loggedOut(currentClient(b)) {
+		b.mu.Lock()
+		b.reconnect = true
+		b.mu.Unlock()
+	}
+	if err := reconnectIfNeeded(b); err != nil {
+		return err
+	}
+
+	return connectionLost(b, nil)
+}
+
+// fetchAllMessages fetches the given items of all messages in the selected mailbox.
+func fetchAllMessages(mbox *imap.MailboxStatus, b *Inbox, messages chan *imap.Message, items ...imap.FetchItem) error {
+	if mbox.Messages == 0 {
+		close(messages)
+		return nil
+	}
+
+	// 1:* instead of 1:mbox.Messages, so messages which arrived after the select are included.
+	uidSet := new(imap.SeqSet)
+	uidSet.AddRange(1, 0)
+	if err := uidFetch(b, uidSet, mergeItems(items), messages); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// compareMessageWithAddresses compares the given message address with the addresses to delete.
-// The ID of a matching message is added to delSeqSet.
-func compareMessageWithAddresses(msg *imap.Message, address []string, mapChan chan map[string]string, delSeqSet *imap.SeqSet) {
+// compareMessageWithAddresses compares the addresses in the given envelope fields with the addresses to delete.
+// The returned map holds the subject of the message for every matching address.
+func compareMessageWithAddresses(b *Inbox, msg *imap.Message, address []string) map[string]string {
 	m := make(map[string]string)
 	for _, addr := range address {
-		for _, from := range msg.Envelope.From {
-			msgAddress := from.Address()
-			if msgAddress == addr {
-				m[addr] = msg.Envelope.Subject
-				delSeqSet.AddNum(msg.SeqNum)
+		for _, from := range envelopeAddresses(msg.Envelope, b.fields) {
+			if b.matcher.Match(addr, from.Address()) {
+				m[addr] = criteria.DecodeHeader(msg.Envelope.Subject)
 			}
 		}
 	}
 
-	mapChan <- m
+	return m
 }
 
 func main() {
@@ -187,38 +844,19 @@ func main() {
 	}
 
 	// Create new inbox
-	inbox, err := New(GMX, cred)
+	inbox, err := New(GMX, cred, WithConfirmDestructive(true))
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// Delete all messages in the inbox folder
-	if err := inbox.DeleteAllMessagesInFolder(true, InboxFolder); err != nil {
-		log.Fatal(err)
-	}
-
-	// Delete all messages in the spam folder
-	if err := inbox.DeleteAllMessagesInFolder(true, GmxSpamFolder); err != nil {
-		log.Fatal(err)
-	}
-
-	// Delete all messages in the trash folder
-	if err := inbox.DeleteAllMessagesInFolder(true, TrashFolder); err != nil {
-		log.Fatal(err)
-	}
-
-	// Delete all messages from the given addresses in the inbox folder
-	if err := inbox.DeleteMessagesInFolderFromAddress(true, InboxFolder, "address1", "address2"); err != nil {
-		log.Fatal(err)
-	}
-
-	// Delete all messages from the given addresses in the spam folder
-	if err := inbox.DeleteMessagesInFolderFromAddress(true, GmxSpamFolder, "address1", "address2"); err != nil {
+	// Delete all messages in the inbox, spam and trash folder
+	if _, err := inbox.DeleteAllMessagesInFolders(true, InboxFolder, GmxSpamFolder, TrashFolder); err != nil {
 		log.Fatal(err)
 	}
 
-	// Delete all messages from the given addresses in the trash folder
-	if err := inbox.DeleteMessagesInFolderFromAddress(true, TrashFolder, "address1", "address2"); err != nil {
+	// Delete all messages from the given addresses in the inbox, spam and trash folder
+	folders := []Folder{InboxFolder, GmxSpamFolder, TrashFolder}
+	if _, err := inbox.DeleteMessagesInFoldersFromAddress(true, folders, "address1", "address2"); err != nil {
 		log.Fatal(err)
 	}
 
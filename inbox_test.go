@@ -0,0 +1,80 @@
+package inbox
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDeleteAllMessagesInFolderFast(t *testing.T) {
+	s := newTestServer(t)
+	for n := 0; n < 3; n++ {
+		s.addMessage(t, "Archive", "a@example.com", fmt.Sprint("message ", n), time.Now())
+	}
+	b := s.dial(t, WithPreserveFlagged(false))
+
+	s.log.Reset()
+	res, err := deleteAllMessagesInFolder(b, true, "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Matched != 3 || res.Deleted != 3 {
+		t.Errorf("Matched %d, Deleted %d, want 3 each", res.Matched, res.Deleted)
+	}
+	if s.sent("UID SEARCH") || s.sent("UID FETCH") {
+		t.Errorf("the fast path enumerated the messages: %v", s.commands())
+	}
+	if n := len(s.mailbox(t, "Archive").Messages); n != 0 {
+		t.Errorf("%d messages left, want 0", n)
+	}
+}
+
+func TestEmptyFolderKeepsLaterMessages(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "before", time.Now())
+	b := s.dial(t, WithPreserveFlagged(false))
+
+	mbox, err := selectFolder(b, "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := s.addMessage(t, "Archive", "a@example.com", "after", time.Now())
+
+	res, err := emptyFolder(b, mbox.UidNext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.deleted != 1 {
+		t.Errorf("deleted %d, want 1", res.deleted)
+	}
+
+	left := s.mailbox(t, "Archive").Messages
+	if len(left) != 1 || left[0].Uid != later {
+		t.Errorf("messages left %d, want only UID %d which arrived after the select", len(left), later)
+	}
+}
+
+// BenchmarkDeleteAllMessagesInFolder compares emptying a folder with a single STORE against enumerating its
+// messages, as done while flagged messages are preserved.
+func BenchmarkDeleteAllMessagesInFolder(bm *testing.B) {
+	for _, bc := range []struct {
+		name     string
+		preserve bool
+	}{{"fast", false}, {"enumerating", true}} {
+		bm.Run(bc.name, func(bm *testing.B) {
+			s := newTestServer(bm)
+			b := s.dial(bm, WithPreserveFlagged(bc.preserve))
+			for n := 0; n < bm.N; n++ {
+				bm.StopTimer()
+				for m := 0; m < 1000; m++ {
+					s.addMessage(bm, "Archive", "a@example.com", "message", time.Now())
+				}
+				bm.StartTimer()
+
+				if _, err := deleteAllMessagesInFolder(b, true, "Archive"); err != nil {
+					bm.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package inbox
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// calendarEvent is the part of a VEVENT needed to decide whether an invitation expired.
+type calendarEvent struct {
+	Summary   string
+	Start     time.Time
+	End       time.Time
+	Recurring bool
+}
+
+// DeleteExpiredInvites deletes calendar invitations whose event ended more than olderThan ago.
+// Invitations are recognized by a text/calendar part, only that part is fetched and parsed.
+// Recurring events (RRULE) are always kept. When expunge is set to "false", the matching events are only listed.
+func (b *Inbox) DeleteExpiredInvites(expunge bool, folder Folder, olderThan time.Duration) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	// Group the invitations by the path of their calendar part, so each group needs a single fetch.
+	type partGroup struct {
+		path   []int
+		uidSet *imap.SeqSet
+	}
+	groups := make(map[string]*partGroup)
+	encodings := make(map[uint32]string)
+	err = findMessages(b, criteria.All(), []imap.FetchItem{imap.FetchBodyStructure}, func(msg *imap.Message) bool {
+		path, encoding, ok := calendarPart(msg.BodyStructure)
+		if !ok {
+			return true
+		}
+
+		key := fmt.Sprint(path)
+		if groups[key] == nil {
+			groups[key] = &partGroup{path: path, uidSet: new(imap.SeqSet)}
+		}
+		groups[key].uidSet.AddNum(msg.Uid)
+		encodings[msg.Uid] = encoding
+		return true
+	})
+	if err != nil {
+		return res, err
+	}
+
+	expiry := now(b).Add(-olderThan)
+	delUIDs := new(imap.SeqSet)
+	log.Println("Expired invitations in", folder+":")
+	for _, group := range groups {
+		section := &imap.BodySectionName{BodyPartName: imap.BodyPartName{Path: group.path}, Peek: true}
+		err := fetchEach(b, group.uidSet, []imap.FetchItem{section.FetchItem()}, func(msg *imap.Message) {
+			body := msg.GetBody(section)
+			if body == nil {
+				return
+			}
+
+			event, err := parseCalendarEvent(decodePart(body, encodings[msg.Uid]))
+			if err != nil {
+				log.Println("Skipping invitation", msg.Uid, "in", folder+":", err)
+				return
+			}
+
+			if event.Recurring || !event.End.Before(expiry) {
+				return
+			}
+
+			log.Println("\t", event.End.Format(time.DateOnly), event.Summary)
+			delUIDs.AddNum(msg.Uid)
+			res.Matched++
+		})
+		if err != nil {
+			return res, err
+		}
+	}
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// fetchEach fetches the messages in uidSet and calls fn for each of them. Unlike fetchBatched, it never stops
+// early, for fetches whose result has to be complete, like the audit entries of messages about to be deleted.
+func fetchEach(b *Inbox, uidSet *imap.SeqSet, items []imap.FetchItem, fn func(*imap.Message)) error {
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- conn(b).UidFetch(uidSet, mergeItems(items), messages)
+	}()
+
+	return consume(b, messages, errChan, fn)
+}
+
+// calendarPart returns the part path and transfer encoding of the first text/calendar part.
+func calendarPart(bs *imap.BodyStructure) (path []int, encoding string, ok bool) {
+	if bs == nil {
+		return nil, "", false
+	}
+
+	bs.Walk(func(p []int, part *imap.BodyStructure) bool {
+		if ok {
+			return false
+		}
+
+		if strings.EqualFold(part.MIMEType, "text") && strings.EqualFold(part.MIMESubType, "calendar") {
+			path, encoding, ok = p, part.Encoding, true
+			return false
+		}
+
+		return true
+	})
+
+	// A single part message is addressed as part 1.
+	if ok && len(path) == 0 {
+		path = []int{1}
+	}
+
+	return path, encoding, ok
+}
+
+// decodePart undoes the content transfer encoding of a fetched part.
+func decodePart(r io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(encoding) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// parseCalendarEvent reads the first VEVENT of an iCalendar payload. DTEND defaults to DTSTART when missing.
+func parseCalendarEvent(r io.Reader) (calendarEvent, error) {
+	var event calendarEvent
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Folded lines continue with a single space or tab.
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return event, err
+	}
+
+	inEvent := false
+	for _, line := range lines {
+		name, params, value := parseCalendarLine(line)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT"):
+			inEvent = true
+		case name == "END" && strings.EqualFold(value, "VEVENT"):
+			inEvent = false
+			if event.Start.IsZero() {
+				return event, errors.New("inbox: invitation without DTSTART")
+			}
+			if event.End.IsZero() {
+				event.End = event.Start
+			}
+			return event, nil
+		case !inEvent:
+		case name == "SUMMARY":
+			event.Summary = value
+		case name == "RRULE":
+			event.Recurring = true
+		case name == "DTSTART" || name == "DTEND":
+			t, err := parseCalendarTime(value, params["TZID"])
+			if err != nil {
+				return event, err
+			}
+			if name == "DTSTART" {
+				event.Start = t
+			} else {
+				event.End = t
+			}
+		}
+	}
+
+	return event, errors.New("inbox: invitation without VEVENT")
+}
+
+// parseCalendarLine splits a content line like "DTSTART;TZID=Europe/Berlin:20240101T100000".
+func parseCalendarLine(line string) (name string, params map[string]string, value string) {
+	// The value starts at the first colon outside of quoted parameter values.
+	quoted := false
+	sep := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			sep = i
+			break
+		}
+	}
+	if sep < 0 {
+		return "", nil, ""
+	}
+
+	fields := strings.Split(line[:sep], ";")
+	params = make(map[string]string)
+	for _, param := range fields[1:] {
+		k, v, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+	}
+
+	return strings.ToUpper(fields[0]), params, line[sep+1:]
+}
+
+// parseCalendarTime parses DATE and DATE-TIME values. Floating times and unknown zones use the local time zone.
+func parseCalendarTime(value, tzid string) (time.Time, error) {
+	loc := time.Local
+	if tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	switch {
+	case len(value) == len("20060102"):
+		return time.ParseInLocation("20060102", value, loc)
+	case strings.HasSuffix(value, "Z"):
+		return time.Parse("20060102T150405Z", value)
+	default:
+		return time.ParseInLocation("20060102T150405", value, loc)
+	}
+}
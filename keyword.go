@@ -0,0 +1,97 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// FlagMessagesFromAddress sets the keyword, like "$Reviewed", on all messages in the folder sent from one of the
+// given addresses and returns the number of flagged messages. Keywords the server can't store permanently
+// are refused before anything is changed.
+func (b *Inbox) FlagMessagesFromAddress(folder Folder, keyword string, addr ...string) (int, error) {
+	defer operation(b)()
+	if err := validateKeyword(keyword); err != nil {
+		return 0, err
+	}
+
+	addr, err := normalizeAddresses(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	mbox, err := selectFolder(b, folder)
+	if err != nil {
+		return 0, err
+	}
+
+	if !keywordPermitted(mbox, keyword) {
+		return 0, fmt.Errorf("inbox: %s doesn't accept the keyword %q permanently", folder, keyword)
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchUid, imap.FetchEnvelope)
+	}()
+
+	uids := new(imap.SeqSet)
+	matched, _ := compare(b, addr, messages, uids)
+	if err := <-errChan; err != nil {
+		return 0, err
+	}
+	observeFetch(b, folder, int(mbox.Messages))
+
+	if matched == 0 {
+		return 0, nil
+	}
+
+	if skipMutation(b, "flag", uids, "in", folder, "with", keyword) {
+		return matched, nil
+	}
+
+	if err := conn(b).UidStore(uids, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{keyword}, nil); err != nil {
+		return 0, err
+	}
+
+	log.Println("Flagged", matched, "messages in", folder, "with", keyword)
+	return matched, nil
+}
+
+// validateKeyword checks the keyword is a valid IMAP atom (RFC 3501 flag-keyword) or a system flag.
+func validateKeyword(keyword string) error {
+	if strings.HasPrefix(keyword, `\`) {
+		for _, flag := range []string{imap.SeenFlag, imap.AnsweredFlag, imap.FlaggedFlag, imap.DeletedFlag, imap.DraftFlag} {
+			if strings.EqualFold(keyword, flag) {
+				return nil
+			}
+		}
+		return fmt.Errorf("inbox: %q is not a system flag", keyword)
+	}
+
+	if keyword == "" || strings.ContainsAny(keyword, `(){ %*"\]`) {
+		return fmt.Errorf("inbox: invalid keyword %q", keyword)
+	}
+
+	for _, r := range keyword {
+		if r <= 0x1f || r >= 0x7f {
+			return fmt.Errorf("inbox: invalid keyword %q", keyword)
+		}
+	}
+
+	return nil
+}
+
+// keywordPermitted reports whether the selected mailbox stores the keyword permanently, either because it is
+// listed in PERMANENTFLAGS or because "\*" allows new keywords.
+func keywordPermitted(mbox *imap.MailboxStatus, keyword string) bool {
+	for _, flag := range mbox.PermanentFlags {
+		if flag == imap.TryCreateFlag || strings.EqualFold(flag, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package inbox
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// sizedMessage is a message of a folder with its RFC822.SIZE.
+type sizedMessage struct {
+	folder Folder
+	uid    uint32
+	size   uint32
+}
+
+// sizeHeap is a min-heap of messages by size, its root is the smallest of the largest messages seen so far.
+type sizeHeap []sizedMessage
+
+func (h sizeHeap) Len() int            { return len(h) }
+func (h sizeHeap) Less(i, j int) bool  { return h[i].size < h[j].size }
+func (h sizeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sizeHeap) Push(x interface{}) { *h = append(*h, x.(sizedMessage)) }
+
+func (h *sizeHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// add keeps the message if it is among the n largest.
+func (h *sizeHeap) add(msg sizedMessage, n int) {
+	if h.Len() < n {
+		heap.Push(h, msg)
+		return
+	}
+
+	if msg.size > (*h)[0].size {
+		(*h)[0] = msg
+		heap.Fix(h, 0)
+	}
+}
+
+// LargestMessages returns the summaries of the n largest messages in the folders by RFC822.SIZE, largest first.
+// Without folders, all selectable folders of the account are searched. Only the sizes of all messages are
+// fetched and at most n are held at a time, the remaining fields are fetched just for the n largest. Bodies are
+// never fetched and the folders are examined read-only. Failing folders don't stop the run unless the error is
+// fatal, see isFatal; the summaries found are returned with errors.Join of the folder errors.
+func (b *Inbox) LargestMessages(n int, folders ...Folder) ([]MessageSummary, error) {
+	defer operation(b)()
+	if n <= 0 {
+		return nil, nil
+	}
+
+	if len(folders) == 0 {
+		infos, err := b.ListFolderInfos()
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if info.Selectable() {
+				folders = append(folders, info.Folder)
+			}
+		}
+	}
+
+	largest := make(sizeHeap, 0, n)
+	var errs []error
+	for _, folder := range folders {
+		if err := largestInFolder(b, folder, n, &largest); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				return nil, errors.Join(errs...)
+			}
+		}
+	}
+
+	byFolder := make(map[Folder][]uint32)
+	for _, msg := range largest {
+		byFolder[msg.folder] = append(byFolder[msg.folder], msg.uid)
+	}
+
+	summaries := make([]MessageSummary, 0, len(largest))
+	for _, folder := range folders {
+		uids := byFolder[folder]
+		if len(uids) == 0 {
+			continue
+		}
+
+		first := len(summaries)
+		err := largestSummaries(b, folder, uids, &summaries)
+		if err == nil {
+			err = addSnippets(b, summaries[first:])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			if isFatal(b, err) {
+				break
+			}
+		}
+	}
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Size > summaries[j].Size })
+	return summaries, errors.Join(errs...)
+}
+
+// largestSummaries appends the summaries of the messages with the UIDs in the folder.
+func largestSummaries(b *Inbox, folder Folder, uids []uint32, summaries *[]MessageSummary) error {
+	if _, err := examineFolder(b, folder); err != nil {
+		return err
+	}
+
+	return findMessages(b, criteria.ByUIDs(uids...), summaryFetchItems(b), func(msg *imap.Message) bool {
+		*summaries = append(*summaries, newSummary(folder, msg))
+		return true
+	})
+}
+
+// largestInFolder adds the n largest messages of the folder to the heap.
+func largestInFolder(b *Inbox, folder Folder, n int, largest *sizeHeap) error {
+	mbox, err := examineFolder(b, folder)
+	if err != nil {
+		return err
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchUid, imap.FetchRFC822Size)
+	}()
+
+	count := 0
+	for msg := range messages {
+		largest.add(sizedMessage{folder: folder, uid: msg.Uid, size: msg.Size}, n)
+		count++
+	}
+	if err := <-errChan; err != nil {
+		return err
+	}
+	observeFetch(b, folder, count)
+
+	return nil
+}
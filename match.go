@@ -0,0 +1,75 @@
+package inbox
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// AddressField is an envelope address field messages are matched against.
+type AddressField int
+
+const (
+	// FromField matches the From header addresses.
+	FromField AddressField = iota
+	// SenderField matches the Sender header, which mailing lists often set to the real origin.
+	SenderField
+	// ReplyToField matches the Reply-To header, which spam often points to a throwaway address while the From
+	// header looks legitimate.
+	ReplyToField
+)
+
+// WithMatchFields sets the envelope fields address matching is done against. Defaults to FromField.
+func WithMatchFields(fields ...AddressField) Option {
+	return func(i *Inbox) {
+		i.fields = fields
+	}
+}
+
+// WithMatchSubdomains lets domain patterns like "example.com" also match subdomains like "mail.example.com".
+func WithMatchSubdomains(match bool) Option {
+	return func(i *Inbox) {
+		i.matcher.MatchSubdomains = match
+	}
+}
+
+// envelopeAddresses returns the addresses of all given fields in the envelope.
+func envelopeAddresses(env *imap.Envelope, fields []AddressField) []*imap.Address {
+	var addrs []*imap.Address
+	for _, field := range fields {
+		switch field {
+		case FromField:
+			addrs = append(addrs, env.From...)
+		case SenderField:
+			addrs = append(addrs, env.Sender...)
+		case ReplyToField:
+			addrs = append(addrs, env.ReplyTo...)
+		}
+	}
+
+	return addrs
+}
+
+// normalizeAddresses reduces user supplied addresses like "Bob <bob@example.com>" to their bare address.
+// Domain patterns are kept as they are. An error is returned for addresses which can't be parsed.
+func normalizeAddresses(addrs []string) ([]string, error) {
+	normalized := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		addr = strings.TrimSpace(addr)
+		if criteria.IsDomainPattern(addr) {
+			normalized = append(normalized, addr)
+			continue
+		}
+
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("inbox: invalid address %q: %w", addr, err)
+		}
+		normalized = append(normalized, parsed.Address)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,70 @@
+package inbox
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// DeleteByMessageIDs deletes the messages with the given Message-IDs from the folder.
+// IDs may be given with or without angle brackets. IDs which weren't found are listed in the result's Unmatched field.
+func (b *Inbox) DeleteByMessageIDs(expunge bool, folder Folder, ids ...string) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: folder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	delUIDs := new(imap.SeqSet)
+	for _, id := range ids {
+		search := imap.NewSearchCriteria()
+		search.Header.Add("Message-Id", normalizeMessageID(id))
+
+		uids, err := conn(b).UidSearch(search)
+		if err != nil {
+			return res, err
+		}
+
+		if len(uids) == 0 {
+			res.Unmatched = append(res.Unmatched, id)
+			continue
+		}
+
+		log.Println("Message to delete:", id)
+		delUIDs.AddNum(uids...)
+		res.Matched += len(uids)
+	}
+
+	if len(res.Unmatched) > 0 {
+		log.Println("Message-IDs not found:", strings.Join(res.Unmatched, ", "))
+	}
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	if err != nil {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// normalizeMessageID wraps the Message-ID in angle brackets, as it appears in the header.
+func normalizeMessageID(id string) string {
+	id = strings.TrimSpace(id)
+	if !strings.HasPrefix(id, "<") {
+		id = "<" + id
+	}
+	if !strings.HasSuffix(id, ">") {
+		id += ">"
+	}
+
+	return id
+}
@@ -0,0 +1,49 @@
+package inbox
+
+import "time"
+
+// MetricsObserver receives telemetry about finished operations. Implementations can export them
+// to Prometheus, statsd or similar without the package depending on any metrics library.
+type MetricsObserver interface {
+	// ObserveFetch is called with the number of messages fetched from a folder.
+	ObserveFetch(folder string, fetched int)
+	// ObserveDelete is called at the end of each delete operation.
+	ObserveDelete(folder string, matched, deleted int, d time.Duration)
+	// ObserveError is called when an operation on a folder failed.
+	ObserveError(folder string, err error)
+}
+
+// WithMetricsObserver reports the outcome of every operation to the given observer.
+func WithMetricsObserver(o MetricsObserver) Option {
+	return func(i *Inbox) {
+		i.metrics = o
+	}
+}
+
+// startDelete returns the start time of a delete operation for observeDelete. The truncation of earlier operations
+// is cleared, see stopEarly.
+func startDelete(b *Inbox) time.Time {
+	b.truncated = false
+	return time.Now()
+}
+
+// observeDelete marks res as Truncated if the deadline or the budget stopped the operation early and reports it to
+// the metrics observer, if any.
+func observeDelete(b *Inbox, start time.Time, res *DeleteResult, err error) {
+	res.Truncated = res.Truncated || b.truncated
+	if b.metrics == nil {
+		return
+	}
+
+	if err != nil {
+		b.metrics.ObserveError(string(res.Folder), err)
+	}
+	b.metrics.ObserveDelete(string(res.Folder), res.Matched, res.Deleted, time.Since(start))
+}
+
+// observeFetch reports the number of fetched messages to the metrics observer, if any.
+func observeFetch(b *Inbox, folder Folder, fetched int) {
+	if b.metrics != nil {
+		b.metrics.ObserveFetch(string(folder), fetched)
+	}
+}
@@ -0,0 +1,164 @@
+package inbox
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// TransferOptions configures Migrate and Export.
+type TransferOptions struct {
+	// DeleteSourceAfterCopy deletes every source message whose copy was confirmed. Messages which couldn't be
+	// written are always kept.
+	DeleteSourceAfterCopy bool
+	// PreserveDateAndFlags appends the messages of Migrate with their original INTERNALDATE and flags, so
+	// archives sorted by date stay in order and read or flagged messages stay so.
+	PreserveDateAndFlags bool
+}
+
+// TransferResult reports which messages of a Migrate or Export were moved and which were left behind.
+type TransferResult struct {
+	Folder Folder
+	// Copied holds the UIDs of the messages written to the destination.
+	Copied []uint32
+	// Failed holds the error of every message which couldn't be written.
+	Failed map[uint32]error
+	// Deleted is the number of source messages removed after their copy was confirmed.
+	Deleted int
+}
+
+// Migrate appends all messages in src matching crit to the folder dest of another account.
+// A copy counts as confirmed once the destination server answered the APPEND with OK.
+func (b *Inbox) Migrate(src Folder, crit criteria.Criteria, dst *Inbox, dest Folder, opts TransferOptions) (TransferResult, error) {
+	defer operation(b)()
+	if dst == b {
+		return TransferResult{Folder: src}, errors.New("inbox: Migrate needs another account, use Move within one account")
+	}
+
+	dest, err := serverFolder(dst, dest)
+	if err != nil {
+		return TransferResult{Folder: src}, err
+	}
+
+	return transferRaw(b, src, crit, opts, func(msg *imap.Message, raw []byte) error {
+		if !opts.PreserveDateAndFlags {
+			return conn(dst).Append(string(dest), nil, time.Time{}, bytes.NewBuffer(raw))
+		}
+
+		return conn(dst).Append(string(dest), appendFlags(msg.Flags), msg.InternalDate, bytes.NewBuffer(raw))
+	})
+}
+
+// appendFlags returns the flags which can be set by APPEND. \Recent is maintained by the server only.
+func appendFlags(flags []string) []string {
+	var kept []string
+	for _, flag := range flags {
+		if !strings.EqualFold(flag, imap.RecentFlag) {
+			kept = append(kept, flag)
+		}
+	}
+
+	return kept
+}
+
+// Export writes all messages in the folder matching crit to dir, one "<uid>.eml" file per message.
+// A copy counts as confirmed once the file was written and synced completely.
+func (b *Inbox) Export(folder Folder, crit criteria.Criteria, dir string, opts TransferOptions) (TransferResult, error) {
+	defer operation(b)()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return TransferResult{Folder: folder}, err
+	}
+
+	return transferRaw(b, folder, crit, opts, func(msg *imap.Message, raw []byte) error {
+		return writeMessageFile(filepath.Join(dir, fmt.Sprintf("%d.eml", msg.Uid)), raw)
+	})
+}
+
+// writeMessageFile writes raw to path via a temporary file, so a failed write never leaves a partial message.
+func writeMessageFile(path string, raw []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".export-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// transferRaw calls write with the raw content of every message in folder matching crit and deletes the
+// messages written successfully when requested.
+func transferRaw(b *Inbox, folder Folder, crit criteria.Criteria, opts TransferOptions, write func(*imap.Message, []byte) error) (res TransferResult, err error) {
+	res = TransferResult{Folder: folder, Failed: make(map[uint32]error)}
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	uids, err := matchingUIDs(b, crit)
+	if err != nil || len(uids) == 0 {
+		return res, err
+	}
+
+	uidSet := new(imap.SeqSet)
+	uidSet.AddNum(uids...)
+	if skipMutation(b, "copy", uidSet, "from", folder) {
+		res.Copied = uids
+		return res, nil
+	}
+
+	section := &imap.BodySectionName{Peek: true}
+	items := []imap.FetchItem{section.FetchItem(), imap.FetchFlags, imap.FetchInternalDate}
+	err = fetchEach(b, uidSet, items, func(msg *imap.Message) {
+		body := msg.GetBody(section)
+		if body == nil {
+			res.Failed[msg.Uid] = errors.New("inbox: server returned no content")
+			return
+		}
+
+		raw, err := io.ReadAll(body)
+		if err == nil {
+			err = write(msg, raw)
+		}
+		if err != nil {
+			log.Println("Copying message", msg.Uid, "failed:", err)
+			res.Failed[msg.Uid] = err
+			return
+		}
+
+		res.Copied = append(res.Copied, msg.Uid)
+	})
+	if err != nil {
+		return res, err
+	}
+
+	if !opts.DeleteSourceAfterCopy || len(res.Copied) == 0 {
+		return res, nil
+	}
+
+	delUIDs := new(imap.SeqSet)
+	delUIDs.AddNum(res.Copied...)
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	res.Deleted = exp.deleted
+	return res, err
+}
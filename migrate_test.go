@@ -0,0 +1,43 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+func TestMigratePreservesDateAndFlags(t *testing.T) {
+	received := time.Date(2019, 3, 14, 15, 9, 26, 0, time.UTC)
+
+	src := newTestServer(t)
+	src.addMessage(t, "Archive", "a@example.com", "old", received, imap.SeenFlag, imap.FlaggedFlag)
+	dst := newTestServer(t)
+	dst.mailbox(t, "Archive")
+
+	b := src.dial(t)
+	res, err := b.Migrate("Archive", criteria.All(), dst.dial(t), "Archive", TransferOptions{PreserveDateAndFlags: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Copied) != 1 {
+		t.Fatalf("Copied = %v, want one message", res.Copied)
+	}
+
+	msgs := dst.mailbox(t, "Archive").Messages
+	if len(msgs) != 1 {
+		t.Fatalf("destination holds %d messages, want 1", len(msgs))
+	}
+	if !msgs[0].Date.Equal(received) {
+		t.Errorf("INTERNALDATE of the migrated message = %v, want %v", msgs[0].Date, received)
+	}
+
+	flags := make(map[string]bool)
+	for _, flag := range msgs[0].Flags {
+		flags[flag] = true
+	}
+	if !flags[imap.SeenFlag] || !flags[imap.FlaggedFlag] {
+		t.Errorf("flags of the migrated message = %v, want %s and %s", msgs[0].Flags, imap.SeenFlag, imap.FlaggedFlag)
+	}
+}
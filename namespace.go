@@ -0,0 +1,213 @@
+package inbox
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/responses"
+)
+
+// namespace is the personal namespace of the account (RFC 2342). Folder names passed to the package are logical
+// names with "/" as delimiter and without the prefix, like "Archive/2023" for "INBOX.Archive.2023" on Dovecot
+// or Courier servers putting every folder below the INBOX.
+type namespace struct {
+	known  bool
+	prefix string
+	delim  string
+}
+
+// serverName translates a logical folder name to the name on the server. Names already carrying the prefix and
+// the INBOX are kept as they are.
+func (ns namespace) serverName(name string) string {
+	if !ns.known || strings.EqualFold(name, imap.InboxName) {
+		return name
+	}
+
+	if ns.prefix != "" && strings.HasPrefix(name, ns.prefix) {
+		return name
+	}
+
+	if ns.delim != "" && ns.delim != "/" {
+		name = strings.ReplaceAll(name, "/", ns.delim)
+	}
+
+	return ns.prefix + name
+}
+
+// logicalName translates a folder name on the server to its logical name.
+func (ns namespace) logicalName(name string) string {
+	if !ns.known || strings.EqualFold(name, imap.InboxName) {
+		return name
+	}
+
+	if ns.prefix != "" && len(name) > len(ns.prefix) && strings.HasPrefix(name, ns.prefix) {
+		name = name[len(ns.prefix):]
+	}
+
+	if ns.delim != "" && ns.delim != "/" {
+		name = strings.ReplaceAll(name, ns.delim, "/")
+	}
+
+	return name
+}
+
+// serverFolder returns the name on the server of a logical or well-known folder.
+func serverFolder(b *Inbox, folder Folder) (Folder, error) {
+	folder, err := b.ResolveFolder(folder)
+	if err != nil {
+		return "", err
+	}
+
+	return Folder(b.namespace.serverName(string(folder))), nil
+}
+
+// sameFolder reports whether both folders name the same folder on the server, after resolving well-known folders
+// and the namespace. The INBOX is case-insensitive, other names aren't.
+func sameFolder(b *Inbox, x, y Folder) (bool, error) {
+	x, err := serverFolder(b, x)
+	if err != nil {
+		return false, err
+	}
+	y, err = serverFolder(b, y)
+	if err != nil {
+		return false, err
+	}
+
+	if strings.EqualFold(string(x), imap.InboxName) {
+		return strings.EqualFold(string(y), imap.InboxName), nil
+	}
+
+	return x == y, nil
+}
+
+// logicalFolder returns the logical folder of a name listed by the server.
+func logicalFolder(b *Inbox, name string) Folder {
+	return Folder(b.namespace.logicalName(name))
+}
+
+// NamespaceDescriptor is a namespace with the prefix and the hierarchy delimiter of its folders. Delimiter is
+// empty on flat servers.
+type NamespaceDescriptor struct {
+	Prefix    string
+	Delimiter string
+}
+
+// Namespaces are the namespaces of the account returned by the NAMESPACE command (RFC 2342).
+type Namespaces struct {
+	// Personal are the namespaces of the user's own folders.
+	Personal []NamespaceDescriptor
+	// Other are the namespaces of other users' folders.
+	Other []NamespaceDescriptor
+	// Shared are the namespaces of shared folders.
+	Shared []NamespaceDescriptor
+}
+
+// Namespaces returns the namespaces of the account. The server must advertise NAMESPACE.
+func (b *Inbox) Namespaces() (*Namespaces, error) {
+	defer operation(b)()
+	if err := requireCapability(b, "NAMESPACE"); err != nil {
+		return nil, err
+	}
+
+	return fetchNamespaces(b)
+}
+
+// SubFolder returns the name on the server of the folder name below parent, built with the prefix and the
+// delimiter of the personal namespace, e.g. "INBOX.Archive.2023" for SubFolder("Archive", "2023") on Dovecot
+// servers putting every folder below the INBOX. Servers without NAMESPACE use the delimiter reported by LIST.
+func (b *Inbox) SubFolder(parent Folder, name ...string) (Folder, error) {
+	defer operation(b)()
+	folder, err := serverFolder(b, parent)
+	if err != nil {
+		return "", err
+	}
+
+	delim := b.namespace.delim
+	if !b.namespace.known {
+		// Without NAMESPACE, the delimiter is the one LIST reports.
+		if delim, err = hierarchyDelimiter(b); err != nil {
+			return "", err
+		}
+	}
+	for _, n := range name {
+		if delim == "" {
+			// Flat servers have no hierarchy, the name is appended as it is.
+			folder += Folder(n)
+			continue
+		}
+		folder += Folder(delim + n)
+	}
+
+	return folder, nil
+}
+
+// fetchNamespaces runs the NAMESPACE command.
+func fetchNamespaces(b *Inbox) (*Namespaces, error) {
+	var ns *Namespaces
+	handler := responses.HandlerFunc(func(resp imap.Resp) error {
+		name, fields, ok := imap.ParseNamedResp(resp)
+		if !ok || name != "NAMESPACE" {
+			return responses.ErrUnhandled
+		}
+
+		ns = new(Namespaces)
+		lists := []*[]NamespaceDescriptor{&ns.Personal, &ns.Other, &ns.Shared}
+		for i, field := range fields {
+			if i < len(lists) {
+				*lists[i] = parseNamespaces(field)
+			}
+		}
+		return nil
+	})
+
+	status, err := conn(b).Execute(&imap.Command{Name: "NAMESPACE"}, handler)
+	if err != nil {
+		return nil, err
+	}
+	if err := status.Err(); err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, errors.New("inbox: no NAMESPACE response")
+	}
+
+	return ns, nil
+}
+
+// parseNamespaces parses a namespace list of the NAMESPACE response, NIL is an empty list.
+func parseNamespaces(field interface{}) []NamespaceDescriptor {
+	list, _ := field.([]interface{})
+	var descs []NamespaceDescriptor
+	for _, item := range list {
+		fields, _ := item.([]interface{})
+		if len(fields) < 2 {
+			continue
+		}
+
+		var desc NamespaceDescriptor
+		desc.Prefix, _ = imap.ParseString(fields[0])
+		desc.Delimiter, _ = imap.ParseString(fields[1])
+		descs = append(descs, desc)
+	}
+
+	return descs
+}
+
+// queryNamespace asks the server for the personal namespace, if it advertises NAMESPACE. The first personal
+// namespace holds the account's folders.
+func queryNamespace(b *Inbox) error {
+	if !supports(b, "NAMESPACE") {
+		return nil
+	}
+
+	ns, err := fetchNamespaces(b)
+	if err != nil {
+		return err
+	}
+
+	if len(ns.Personal) > 0 {
+		b.namespace = namespace{known: true, prefix: ns.Personal[0].Prefix, delim: ns.Personal[0].Delimiter}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package inbox
+
+import (
+	"testing"
+
+	"github.com/emersion/go-imap/backend/memory"
+)
+
+func TestSameFolder(t *testing.T) {
+	b := &Inbox{
+		namespace: namespace{known: true, prefix: "INBOX.", delim: "."},
+		resolved:  map[Folder]Folder{WellKnownArchive: "INBOX.Archive"},
+	}
+
+	tests := []struct {
+		x, y Folder
+		want bool
+	}{
+		{"INBOX", "Inbox", true},
+		{"inbox", "INBOX", true},
+		{WellKnownArchive, "Archive", true},
+		{WellKnownArchive, "INBOX.Archive", true},
+		{"Archive/2023", "INBOX.Archive.2023", true},
+		{"Archive", "archive", false},
+		{"INBOX", "Archive", false},
+	}
+
+	for _, tt := range tests {
+		got, err := sameFolder(b, tt.x, tt.y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("sameFolder(%q, %q) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestSubFolderListDelimiter(t *testing.T) {
+	delim := memory.Delimiter
+	memory.Delimiter = "."
+	t.Cleanup(func() { memory.Delimiter = delim })
+
+	s := newTestServer(t)
+	b := s.dial(t)
+	if b.namespace.known {
+		t.Skip("the test server advertises NAMESPACE")
+	}
+
+	got, err := b.SubFolder("Archive", "2023", "03")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Archive.2023.03" {
+		t.Errorf("SubFolder = %q, want Archive.2023.03", got)
+	}
+}
@@ -0,0 +1,129 @@
+package inbox
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// newsletterSection fetches the headers mailing lists and newsletters are recognized by.
+var newsletterSection = criteria.HeaderSection("List-Id", "List-Unsubscribe")
+
+// ListCount is the number of kept and matched issues of a newsletter.
+type ListCount struct {
+	Kept    int
+	Matched int
+}
+
+// NewsletterResult is the outcome of TrimNewsletters.
+type NewsletterResult struct {
+	DeleteResult
+	// Lists holds the counts by newsletter, keyed by its List-Id or, without one, by the sender's address.
+	Lists map[string]ListCount
+}
+
+// newsletterIssue is a message of a newsletter.
+type newsletterIssue struct {
+	uid  uint32
+	date time.Time
+}
+
+// TrimNewsletters keeps only the newest keepPerList issues of every newsletter in the folder and deletes the older
+// ones. Newsletters are messages with a List-Id or List-Unsubscribe header, grouped by List-Id or, without one, by
+// the sender's address. Issues received less than minAge ago are never deleted, regardless of their count.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) TrimNewsletters(expunge bool, folder Folder, keepPerList int, minAge time.Duration) (res NewsletterResult, err error) {
+	defer operation(b)()
+	res = NewsletterResult{DeleteResult: DeleteResult{Folder: folder}, Lists: make(map[string]ListCount)}
+	defer func(start time.Time) { observeDelete(b, start, &res.DeleteResult, err) }(startDelete(b))
+
+	if keepPerList < 0 {
+		keepPerList = 0
+	}
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	listID := imap.NewSearchCriteria()
+	listID.Header.Add("List-Id", "")
+	unsubscribe := imap.NewSearchCriteria()
+	unsubscribe.Header.Add("List-Unsubscribe", "")
+	search := imap.NewSearchCriteria()
+	search.Or = [][2]*imap.SearchCriteria{{listID, unsubscribe}}
+
+	uids, err := conn(b).UidSearch(search)
+	if err != nil || len(uids) == 0 {
+		return res, err
+	}
+
+	candidates := new(imap.SeqSet)
+	candidates.AddNum(uids...)
+	lists := make(map[string][]newsletterIssue)
+	items := []imap.FetchItem{imap.FetchEnvelope, imap.FetchInternalDate, newsletterSection.FetchItem()}
+	err = fetchEach(b, candidates, items, func(msg *imap.Message) {
+		key := newsletterKey(msg)
+		if key == "" {
+			return
+		}
+
+		date := msg.InternalDate
+		if msg.Envelope != nil && !msg.Envelope.Date.IsZero() {
+			date = msg.Envelope.Date
+		}
+		lists[key] = append(lists[key], newsletterIssue{uid: msg.Uid, date: date})
+	})
+	if err != nil {
+		return res, err
+	}
+
+	cutoff := now(b).Add(-minAge)
+	delUIDs := new(imap.SeqSet)
+	for key, issues := range lists {
+		sort.Slice(issues, func(i, j int) bool { return issues[i].date.After(issues[j].date) })
+
+		var count ListCount
+		for n, issue := range issues {
+			if n < keepPerList || issue.date.After(cutoff) {
+				count.Kept++
+				continue
+			}
+			count.Matched++
+			delUIDs.AddNum(issue.uid)
+		}
+		res.Lists[key] = count
+		res.Matched += count.Matched
+
+		if count.Matched > 0 {
+			log.Println("\t", key+":", "keeping", count.Kept, "deleting", count.Matched)
+		}
+	}
+	log.Println("Newsletter issues to delete in", folder+":", res.Matched)
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res.DeleteResult)
+	return res, err
+}
+
+// newsletterKey returns the List-Id of the message, or the sender's address if it has none.
+func newsletterKey(msg *imap.Message) string {
+	for _, value := range criteria.HeaderValues(msg, newsletterSection, "List-Id") {
+		if id := criteria.NormalizeListID(value); id != "" {
+			return id
+		}
+	}
+
+	if msg.Envelope == nil || len(msg.Envelope.From) == 0 {
+		return ""
+	}
+
+	return strings.ToLower(msg.Envelope.From[0].Address())
+}
@@ -0,0 +1,116 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+// Every deletion without matches must return cleanly without sending a STORE or EXPUNGE, some servers reject a
+// STORE with an empty set.
+func TestDeleteWithoutMatches(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "z@example.com", "keep", time.Now())
+	s.mailbox(t, "Drafts")
+	s.mailbox(t, "Sent")
+	s.addMessage(t, RecycleFolder, "r@example.com", "recycled", time.Now())
+	b := s.dial(t)
+	s.log.Reset()
+
+	ops := map[string]func() (int, error){
+		"Delete": func() (int, error) {
+			res, err := b.Delete(true, "Archive", criteria.FromAny("a@example.com"))
+			return res.Deleted, err
+		},
+		"DeleteMatchingAny": func() (int, error) {
+			res, err := b.DeleteMatchingAny(true, "Archive", criteria.FromAny("a@example.com"))
+			return res.Deleted, err
+		},
+		"DeleteUIDRange": func() (int, error) {
+			res, err := b.DeleteUIDRange(true, "Archive", 100, 200)
+			return res.Deleted, err
+		},
+		"DeleteBounces": func() (int, error) {
+			res, err := b.DeleteBounces(true, "Archive", 0)
+			return res.Deleted, err
+		},
+		"DeleteMessagesFromListID": func() (int, error) {
+			res, err := b.DeleteMessagesFromListID(true, "Archive", "list.example.com")
+			return res.Deleted, err
+		},
+		"DeleteAutoReplies": func() (int, error) {
+			res, err := b.DeleteAutoReplies(true, "Archive", 0)
+			return res.Deleted, err
+		},
+		"DeleteMessagesFailingAuth": func() (int, error) {
+			res, err := b.DeleteMessagesFailingAuth(true, "Archive", "dkim")
+			return res.Deleted, err
+		},
+		"DeleteWithAttachmentTypes": func() (int, error) {
+			res, err := b.DeleteWithAttachmentTypes(true, "Archive", []string{"*.exe"})
+			return res.Deleted, err
+		},
+		"DeleteByMessageIDs": func() (int, error) {
+			res, err := b.DeleteByMessageIDs(true, "Archive", "<missing@example.org>")
+			return res.Deleted, err
+		},
+		"DeleteExpiredInvites": func() (int, error) {
+			res, err := b.DeleteExpiredInvites(true, "Archive", 0)
+			return res.Deleted, err
+		},
+		"DeleteMessagesInFolderFromAddress": func() (int, error) {
+			return 0, b.DeleteMessagesInFolderFromAddress(true, "Archive", "a@example.com")
+		},
+		"DeleteMessagesInFolderNotFromAddress": func() (int, error) {
+			res, err := b.DeleteMessagesInFolderNotFromAddress(true, "Archive", "z@example.com")
+			return res.Deleted, err
+		},
+		"DeleteOldDrafts": func() (int, error) {
+			res, err := b.DeleteOldDrafts(true, time.Hour)
+			return res.Deleted, err
+		},
+		"DeleteSentTo": func() (int, error) {
+			res, err := b.DeleteSentTo(true, "a@example.com")
+			return res.Deleted, err
+		},
+		"EmptyRecycle": func() (int, error) {
+			res, err := b.EmptyRecycle(time.Hour)
+			return res.Deleted, err
+		},
+	}
+
+	for name, op := range ops {
+		deleted, err := op()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if deleted != 0 {
+			t.Errorf("%s deleted %d messages, want 0", name, deleted)
+		}
+		for _, cmd := range s.commands() {
+			switch cmd {
+			case "STORE", "UID STORE", "EXPUNGE", "UID EXPUNGE":
+				t.Errorf("%s sent %s without matches", name, cmd)
+			}
+		}
+		s.log.Reset()
+	}
+
+	if n := len(s.mailbox(t, "Archive").Messages); n != 1 {
+		t.Errorf("Archive holds %d messages, want 1", n)
+	}
+}
+
+func TestDeleteMessagesPermanentlyNil(t *testing.T) {
+	s := newTestServer(t)
+	b := s.dial(t)
+	s.log.Reset()
+
+	if _, err := deleteMessagesPermanently(b, nil); err != nil {
+		t.Errorf("deleteMessagesPermanently(nil) = %v", err)
+	}
+	if cmds := s.commands(); len(cmds) > 0 {
+		t.Errorf("deleteMessagesPermanently(nil) sent %v", cmds)
+	}
+}
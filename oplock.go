@@ -0,0 +1,69 @@
+package inbox
+
+import "sync"
+
+// opLock lets operations run side by side, while Reauthenticate waits for all of them to finish and holds off new
+// ones during the re-login. Operations nest, e.g. DeleteAllMessagesInFolders runs the folder deletes, so a waiting
+// Reauthenticate doesn't block further shared holds; it may wait as long as operations keep overlapping.
+// The zero value is unlocked.
+type opLock struct {
+	mu        sync.Mutex
+	cond      sync.Cond
+	running   int
+	exclusive bool
+}
+
+// wait blocks until the next change of the lock. l.mu must be held.
+func (l *opLock) wait() {
+	if l.cond.L == nil {
+		l.cond.L = &l.mu
+	}
+	l.cond.Wait()
+}
+
+// rlock marks an operation running, after a running re-login finished.
+func (l *opLock) rlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.exclusive {
+		l.wait()
+	}
+	l.running++
+}
+
+func (l *opLock) runlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.running--
+	if l.running == 0 {
+		l.cond.Broadcast()
+	}
+}
+
+// lock waits until no operation runs and keeps new ones from starting until unlock.
+func (l *opLock) lock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.exclusive || l.running > 0 {
+		l.wait()
+	}
+	l.exclusive = true
+}
+
+func (l *opLock) unlock() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.exclusive = false
+	l.cond.Broadcast()
+}
+
+// operation marks an operation of the Inbox running until the returned func is called, so Reauthenticate doesn't
+// replace the connection under it. Public methods sending commands start with defer operation(b)().
+func operation(b *Inbox) func() {
+	b.ops.rlock()
+	return b.ops.runlock
+}
@@ -0,0 +1,45 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpLockNestsWhileReloginWaits(t *testing.T) {
+	var l opLock
+	l.rlock()
+
+	locked := make(chan struct{})
+	go func() {
+		l.lock()
+		close(locked)
+		l.unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	// A nested operation must not wait for the re-login, which waits for the outer one.
+	nested := make(chan struct{})
+	go func() {
+		l.rlock()
+		l.runlock()
+		close(nested)
+	}()
+	select {
+	case <-nested:
+	case <-time.After(time.Second):
+		t.Fatal("nested rlock blocked behind the waiting lock")
+	}
+
+	select {
+	case <-locked:
+		t.Fatal("lock acquired while an operation runs")
+	default:
+	}
+
+	l.runlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("lock not acquired after the operation finished")
+	}
+}
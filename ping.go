@@ -0,0 +1,61 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// pingTimeout is the deadline of Ping when ctx has none.
+const pingTimeout = 10 * time.Second
+
+var (
+	// ErrConnectionLost is returned when the server doesn't answer anymore or closed the connection.
+	ErrConnectionLost = errors.New("inbox: connection lost")
+	// ErrNotAuthenticated is returned when the connection is open, but not logged in.
+	ErrNotAuthenticated = errors.New("inbox: not authenticated")
+)
+
+// Ping checks whether the connection is still usable by sending NOOP. It returns ErrConnectionLost if the server
+// doesn't answer in time, ErrNotAuthenticated if the session isn't logged in and ErrClosed after Logout.
+// The selected folder stays selected.
+func (b *Inbox) Ping(ctx context.Context) error {
+	defer operation(b)()
+	if err := checkOpen(b); err != nil {
+		return err
+	}
+
+	select {
+	case <-currentClient(b).LoggedOut():
+		return connectionLost(b, nil)
+	default:
+	}
+
+	if currentClient(b).State()&imap.AuthenticatedState == 0 {
+		return ErrNotAuthenticated
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, pingTimeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- conn(b).Noop()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrConnectionLost, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %v", ErrConnectionLost, ctx.Err())
+	}
+}
@@ -0,0 +1,244 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// Rule selects messages of a folder. The name tells plans which rule matched a message.
+type Rule struct {
+	Name     string
+	Folder   Folder
+	Criteria criteria.Criteria
+}
+
+// PlanKey identifies a message across runs. UIDs are only stable as long as the UIDVALIDITY of the folder is.
+type PlanKey struct {
+	Folder      Folder `json:"folder"`
+	UIDValidity uint32 `json:"uidValidity"`
+	UID         uint32 `json:"uid"`
+}
+
+func (k PlanKey) String() string {
+	return fmt.Sprintf("%s/%d/%d", k.Folder, k.UIDValidity, k.UID)
+}
+
+// less orders keys by folder, UIDVALIDITY and UID.
+func (k PlanKey) less(o PlanKey) bool {
+	if k.Folder != o.Folder {
+		return k.Folder < o.Folder
+	}
+	if k.UIDValidity != o.UIDValidity {
+		return k.UIDValidity < o.UIDValidity
+	}
+	return k.UID < o.UID
+}
+
+// PlanEntry is a message a plan would delete.
+type PlanEntry struct {
+	Key     PlanKey `json:"key"`
+	Rule    string  `json:"rule"`
+	Subject string  `json:"subject"`
+	// Snippet is the beginning of the text, only set with WithSnippets.
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// PlanFolder records the messages present in a folder when the plan was made.
+type PlanFolder struct {
+	UIDValidity uint32 `json:"uidValidity"`
+	// UIDs is the UID set of all messages in the folder.
+	UIDs string `json:"uids"`
+}
+
+// Plan is the outcome of a dry run of rules. It can be stored as JSON and compared with ComparePlans.
+type Plan struct {
+	Entries []PlanEntry           `json:"entries"`
+	Folders map[Folder]PlanFolder `json:"folders"`
+}
+
+// present reports whether the message still existed when the plan was made. It is false for folders the plan
+// didn't examine.
+func (p *Plan) present(key PlanKey) bool {
+	f, ok := p.Folders[key.Folder]
+	if !ok || f.UIDValidity != key.UIDValidity {
+		return false
+	}
+
+	uids, err := imap.ParseSeqSet(f.UIDs)
+	return err == nil && uids.Contains(key.UID)
+}
+
+// PlanRules makes a plan of the messages the rules match, without changing anything. A message matched by
+// several rules is attributed to the first one. Folders are examined read-only. A failing rule doesn't stop the
+// others unless the error is fatal, see isFatal; the plan holds the successful rules and the errors are joined.
+func (b *Inbox) PlanRules(rules ...Rule) (*Plan, error) {
+	defer operation(b)()
+	plan := &Plan{Folders: make(map[Folder]PlanFolder)}
+	matched := make(map[PlanKey]bool)
+	var errs []error
+	for _, rule := range rules {
+		first := len(plan.Entries)
+		if err := planRule(b, plan, matched, rule); err != nil {
+			// Drop what the failing rule added, so later rules can still match its messages.
+			for _, e := range plan.Entries[first:] {
+				delete(matched, e.Key)
+			}
+			plan.Entries = plan.Entries[:first]
+
+			errs = append(errs, fmt.Errorf("%s: %w", rule.Folder, err))
+			if isFatal(b, err) {
+				break
+			}
+		}
+	}
+
+	return plan, errors.Join(errs...)
+}
+
+// planRule adds the messages the rule matches to the plan, skipping those already matched.
+func planRule(b *Inbox, plan *Plan, matched map[PlanKey]bool, rule Rule) error {
+	mbox, err := examineFolder(b, rule.Folder)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := plan.Folders[rule.Folder]; !ok {
+		uids, err := searchUIDs(b, criteria.All())
+		if err != nil {
+			return err
+		}
+
+		all := new(imap.SeqSet)
+		all.AddNum(uids...)
+		plan.Folders[rule.Folder] = PlanFolder{UIDValidity: mbox.UidValidity, UIDs: all.String()}
+	}
+
+	first := len(plan.Entries)
+	err = findMessages(b, rule.Criteria, []imap.FetchItem{imap.FetchEnvelope}, func(msg *imap.Message) bool {
+		key := PlanKey{Folder: rule.Folder, UIDValidity: mbox.UidValidity, UID: msg.Uid}
+		if matched[key] {
+			return true
+		}
+		matched[key] = true
+
+		entry := PlanEntry{Key: key, Rule: rule.Name}
+		if msg.Envelope != nil {
+			entry.Subject = criteria.DecodeHeader(msg.Envelope.Subject)
+		}
+		plan.Entries = append(plan.Entries, entry)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	return addPlanSnippets(b, plan.Entries[first:])
+}
+
+// addPlanSnippets sets the snippets of the entries of messages in the selected folder, if enabled.
+func addPlanSnippets(b *Inbox, entries []PlanEntry) error {
+	uids := make([]uint32, len(entries))
+	for i, e := range entries {
+		uids[i] = e.Key.UID
+	}
+
+	snippets, err := fetchSnippets(b, uids)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		entries[i].Snippet = snippets[entries[i].Key.UID]
+	}
+
+	return nil
+}
+
+// RuleChange is a message matched by a different rule than before.
+type RuleChange struct {
+	Key     PlanKey `json:"key"`
+	OldRule string  `json:"oldRule"`
+	NewRule string  `json:"newRule"`
+	Subject string  `json:"subject"`
+}
+
+// PlanDiff lists the differences between two plans.
+type PlanDiff struct {
+	// Added are the messages only the new plan matches.
+	Added []PlanEntry `json:"added"`
+	// Removed are the messages still present, but no longer matched.
+	Removed []PlanEntry `json:"removed"`
+	// Changed are the messages matched by a different rule.
+	Changed []RuleChange `json:"changed"`
+	// Gone are the messages of the old plan which no longer exist, or whose folder's UIDVALIDITY changed.
+	Gone []PlanEntry `json:"gone"`
+}
+
+// ComparePlans returns what changed from the old to the new plan.
+func ComparePlans(old, new *Plan) PlanDiff {
+	newEntries := make(map[PlanKey]PlanEntry, len(new.Entries))
+	for _, e := range new.Entries {
+		newEntries[e.Key] = e
+	}
+
+	var diff PlanDiff
+	oldKeys := make(map[PlanKey]bool, len(old.Entries))
+	for _, e := range old.Entries {
+		oldKeys[e.Key] = true
+		n, ok := newEntries[e.Key]
+		switch {
+		case ok && n.Rule != e.Rule:
+			diff.Changed = append(diff.Changed, RuleChange{Key: e.Key, OldRule: e.Rule, NewRule: n.Rule, Subject: e.Subject})
+		case ok:
+		case new.present(e.Key):
+			diff.Removed = append(diff.Removed, e)
+		default:
+			diff.Gone = append(diff.Gone, e)
+		}
+	}
+
+	for _, e := range new.Entries {
+		if !oldKeys[e.Key] {
+			diff.Added = append(diff.Added, e)
+		}
+	}
+
+	sortEntries(diff.Added)
+	sortEntries(diff.Removed)
+	sortEntries(diff.Gone)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Key.less(diff.Changed[j].Key) })
+
+	return diff
+}
+
+func sortEntries(entries []PlanEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key.less(entries[j].Key) })
+}
+
+// Empty reports whether the plans didn't differ.
+func (d PlanDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && len(d.Gone) == 0
+}
+
+func (d PlanDiff) String() string {
+	var sb strings.Builder
+	for _, e := range d.Added {
+		fmt.Fprintf(&sb, "+ %s [%s] %s\n", e.Key, e.Rule, e.Subject)
+	}
+	for _, e := range d.Removed {
+		fmt.Fprintf(&sb, "- %s [%s] %s\n", e.Key, e.Rule, e.Subject)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(&sb, "~ %s [%s -> %s] %s\n", c.Key, c.OldRule, c.NewRule, c.Subject)
+	}
+	for _, e := range d.Gone {
+		fmt.Fprintf(&sb, "x %s [%s] %s (gone)\n", e.Key, e.Rule, e.Subject)
+	}
+
+	return sb.String()
+}
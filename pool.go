@@ -0,0 +1,133 @@
+package inbox
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+// Pool holds several connections to the same account, so operations on different folders run in parallel.
+// Only Delete and the multi-folder deletions have methods of their own, which run every folder on a connection of
+// its own. Every other Inbox operation is called through Do, which checks out one connection for the call.
+// The connections share the rate limit and the envelope cache; WithStateFile and WithResumeState must not be used
+// with a Pool, as parallel runs would overwrite their state.
+type Pool struct {
+	conns chan *Inbox
+	all   []*Inbox
+}
+
+// NewPool opens size connections to the account, configured with opts like New.
+func NewPool(provider ImapProvider, cred *Credentials, size int, opts ...Option) (*Pool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("inbox: invalid pool size %d", size)
+	}
+
+	p := &Pool{conns: make(chan *Inbox, size)}
+	for i := 0; i < size; i++ {
+		ib, err := New(provider, cred, opts...)
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+
+		if i > 0 {
+			// The rate limit applies to the account, not a single connection.
+			ib.limiter = p.all[0].limiter
+			ib.cache = p.all[0].cache
+		}
+
+		p.all = append(p.all, ib)
+		p.conns <- ib
+	}
+
+	return p, nil
+}
+
+// Do calls fn with a connection checked out for the duration of the call, e.g. to run an Inbox operation Pool has
+// no method for. fn must not keep the Inbox after returning.
+func (p *Pool) Do(fn func(*Inbox) error) error {
+	ib := <-p.conns
+	defer func() { p.conns <- ib }()
+
+	return fn(ib)
+}
+
+// Delete is like Inbox.Delete on a connection of the pool.
+func (p *Pool) Delete(expunge bool, folder Folder, crit criteria.Criteria) (res DeleteResult, err error) {
+	err = p.Do(func(ib *Inbox) error {
+		res, err = ib.Delete(expunge, folder, crit)
+		return err
+	})
+
+	return res, err
+}
+
+// DeleteAllMessagesInFolders is like Inbox.DeleteAllMessagesInFolders, cleaning the folders in parallel.
+func (p *Pool) DeleteAllMessagesInFolders(expunge bool, folders ...Folder) (map[Folder]DeleteResult, error) {
+	return p.forEachFolder(folders, func(ib *Inbox, folder Folder) (DeleteResult, error) {
+		if err := checkInboxConfirmed(ib, expunge, folder); err != nil {
+			return DeleteResult{Folder: folder}, err
+		}
+
+		return deleteAllMessagesInFolder(ib, expunge, folder)
+	})
+}
+
+// DeleteMessagesInFoldersFromAddress is like Inbox.DeleteMessagesInFoldersFromAddress, cleaning the folders
+// in parallel.
+func (p *Pool) DeleteMessagesInFoldersFromAddress(expunge bool, folders []Folder, addr ...string) (map[Folder]DeleteResult, error) {
+	return p.forEachFolder(folders, func(ib *Inbox, folder Folder) (DeleteResult, error) {
+		return deleteMessagesInFolderFromAddress(ib, expunge, folder, addr)
+	})
+}
+
+// forEachFolder runs fn for every folder in parallel, each on a connection of its own, and collects the results
+// like forEachFolder, failing folders with their error in Err.
+func (p *Pool) forEachFolder(folders []Folder, fn func(*Inbox, Folder) (DeleteResult, error)) (map[Folder]DeleteResult, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[Folder]DeleteResult, len(folders))
+	var errs []error
+	for _, folder := range folders {
+		wg.Add(1)
+		go func(folder Folder) {
+			defer wg.Done()
+
+			var res DeleteResult
+			err := p.Do(func(ib *Inbox) error {
+				var err error
+				res, err = fn(ib, folder)
+				return err
+			})
+
+			if res.Folder == "" {
+				res.Folder = folder
+			}
+			res.Err = err
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[folder] = res
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", folder, err))
+			}
+		}(folder)
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// Close logs out all connections of the pool.
+func (p *Pool) Close() error {
+	var errs []error
+	for _, ib := range p.all {
+		if err := ib.Logout(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,38 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPoolDo(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	s.addMessage(t, "Other", "b@example.com", "second", time.Now())
+
+	p, err := NewPool(ImapProvider(s.addr), &Credentials{Username: "username", Password: "password"}, 2, WithTLSConfig(s.client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	// Operations without a Pool method of their own run through Do.
+	var n int
+	err = p.Do(func(ib *Inbox) error {
+		n, err = ib.MessageCount("Archive")
+		return err
+	})
+	if err != nil || n != 1 {
+		t.Errorf("MessageCount through Do = %d, %v, want 1", n, err)
+	}
+
+	results, err := p.DeleteAllMessagesInFolders(true, "Archive", "Other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, folder := range []Folder{"Archive", "Other"} {
+		if results[folder].Deleted != 1 {
+			t.Errorf("deleted %d messages in %s, want 1", results[folder].Deleted, folder)
+		}
+	}
+}
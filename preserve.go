@@ -0,0 +1,60 @@
+package inbox
+
+import (
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// gmailImportantLabel is the system label Gmail marks important messages with.
+const gmailImportantLabel = `\Important`
+
+// WithPreserveFlagged spares \Flagged messages, and on Gmail those labeled \Important, from the bulk deletes
+// DeleteAllMessagesInFolder(s) and ApplyRetention. Defaults to true. Spared messages are counted as Preserved.
+func WithPreserveFlagged(preserve bool) Option {
+	return func(i *Inbox) {
+		i.preserveFlagged = preserve
+	}
+}
+
+// preserve removes the flagged and important messages from the UIDs of the selected folder, unless disabled
+// with WithPreserveFlagged(false). It returns the remaining UIDs and the number of removed ones.
+func preserve(b *Inbox, uids []uint32) ([]uint32, int, error) {
+	if !b.preserveFlagged || len(uids) == 0 {
+		return uids, 0, nil
+	}
+
+	set := new(imap.SeqSet)
+	set.AddNum(uids...)
+	search := imap.NewSearchCriteria()
+	search.Uid = set
+	search.WithFlags = []string{imap.FlaggedFlag}
+	flagged, err := conn(b).UidSearch(search)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if supports(b, criteria.GmailCapability) {
+		important, err := uidSearchRaw(b, []interface{}{imap.RawString("UID"), set, imap.RawString("X-GM-LABELS"), gmailImportantLabel})
+		if err != nil {
+			return nil, 0, err
+		}
+		flagged = append(flagged, important...)
+	}
+
+	spared := make(map[uint32]bool, len(flagged))
+	for _, uid := range flagged {
+		spared[uid] = true
+	}
+	if len(spared) == 0 {
+		return uids, 0, nil
+	}
+
+	kept := make([]uint32, 0, len(uids)-len(spared))
+	for _, uid := range uids {
+		if !spared[uid] {
+			kept = append(kept, uid)
+		}
+	}
+
+	return kept, len(uids) - len(kept), nil
+}
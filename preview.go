@@ -0,0 +1,79 @@
+package inbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// FolderSummary describes what deleting all messages of a folder would remove.
+type FolderSummary struct {
+	Folder   Folder
+	Messages int
+	// Oldest and Newest are the internal dates of the first and the last message.
+	Oldest time.Time
+	Newest time.Time
+	// TotalSize is the sum of all message sizes in bytes.
+	TotalSize uint64
+}
+
+// PreviewDeleteAll returns a summary of what DeleteAllMessagesInFolder would delete, without changing anything.
+// Empty folders are recognized with STATUS, without selecting them.
+func (b *Inbox) PreviewDeleteAll(folder Folder) (FolderSummary, error) {
+	defer operation(b)()
+	if n, err := b.MessageCount(folder); err != nil || n == 0 {
+		return FolderSummary{Folder: folder}, err
+	}
+
+	mbox, err := examineFolder(b, folder)
+	if err != nil {
+		return FolderSummary{Folder: folder}, err
+	}
+
+	return summarizeFolder(b, folder, mbox)
+}
+
+// summarizeFolder summarizes the selected folder. Only sizes of all messages and the dates of the first and
+// the last message are fetched.
+func summarizeFolder(b *Inbox, folder Folder, mbox *imap.MailboxStatus) (FolderSummary, error) {
+	summary := FolderSummary{Folder: folder, Messages: int(mbox.Messages)}
+	if mbox.Messages == 0 {
+		return summary, nil
+	}
+
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchRFC822Size)
+	}()
+	for msg := range messages {
+		summary.TotalSize += uint64(msg.Size)
+	}
+	if err := <-errChan; err != nil {
+		return summary, err
+	}
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(1, mbox.Messages)
+	messages = make(chan *imap.Message, 2)
+	go func() {
+		errChan <- conn(b).Fetch(seqSet, []imap.FetchItem{imap.FetchInternalDate}, messages)
+	}()
+	for msg := range messages {
+		if msg.SeqNum == 1 {
+			summary.Oldest = msg.InternalDate
+		}
+		if msg.SeqNum == mbox.Messages {
+			summary.Newest = msg.InternalDate
+		}
+	}
+
+	return summary, <-errChan
+}
+
+// logFolderSummary logs the summary of a safe mode delete-all.
+func logFolderSummary(s FolderSummary) {
+	log.Printf("Would delete %d messages (%d bytes) in %s, from %s to %s",
+		s.Messages, s.TotalSize, s.Folder, s.Oldest.Format(time.DateOnly), s.Newest.Format(time.DateOnly))
+}
@@ -0,0 +1,75 @@
+package inbox
+
+import "time"
+
+const (
+	// progressInterval is the least time between two rate samples and progress reports.
+	progressInterval = time.Second
+	// progressMinSamples is the number of rate samples needed before an ETA is estimated.
+	progressMinSamples = 3
+	// progressSmoothing is the weight of the newest sample in the moving average of the rate.
+	progressSmoothing = 0.3
+)
+
+// progressTracker estimates the rate and remaining time of the messages processed by a scan.
+type progressTracker struct {
+	total     int
+	processed int
+	samples   int
+	rate      float64
+
+	lastTime      time.Time
+	lastProcessed int
+}
+
+// startProgress begins tracking a scan of total messages.
+func startProgress(b *Inbox, total int) {
+	if b.progress == nil {
+		return
+	}
+
+	b.tracker = &progressTracker{total: total, lastTime: time.Now()}
+}
+
+// advanceProgress counts n processed messages and reports the progress at most every progressInterval,
+// and once the scan is complete.
+func advanceProgress(b *Inbox, n int) {
+	t := b.tracker
+	if t == nil {
+		return
+	}
+
+	t.processed += n
+	now := time.Now()
+	elapsed := now.Sub(t.lastTime)
+	done := t.processed >= t.total
+	if elapsed < progressInterval && !done {
+		return
+	}
+
+	if elapsed > 0 {
+		sample := float64(t.processed-t.lastProcessed) / elapsed.Seconds()
+		if t.samples == 0 {
+			t.rate = sample
+		} else {
+			t.rate = progressSmoothing*sample + (1-progressSmoothing)*t.rate
+		}
+		t.samples++
+	}
+	t.lastTime = now
+	t.lastProcessed = t.processed
+
+	p := Progress{Processed: t.processed, Total: t.total, ETA: -1}
+	if t.samples >= progressMinSamples || done {
+		p.Rate = t.rate
+	}
+	switch {
+	case done:
+		p.ETA = 0
+		b.tracker = nil
+	case p.Rate > 0:
+		p.ETA = time.Duration(float64(t.total-t.processed) / p.Rate * float64(time.Second))
+	}
+
+	reportProgress(b, p)
+}
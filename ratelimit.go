@@ -0,0 +1,91 @@
+package inbox
+
+import (
+	"time"
+
+	"github.com/emersion/go-imap/client"
+	"golang.org/x/time/rate"
+)
+
+// Progress reports the state of a running operation to the callback given with WithProgress.
+type Progress struct {
+	// Waited is how long the last command was delayed by WithRateLimit.
+	Waited time.Duration
+	// TotalWaited sums all delays of the Inbox so far.
+	TotalWaited time.Duration
+
+	// Processed is the number of messages a running scan has looked at, out of Total.
+	Processed int
+	Total     int
+	// Rate is the moving average of the messages processed per second, zero until enough samples were taken.
+	Rate float64
+	// ETA is the estimated time until the scan completes, negative while unknown.
+	ETA time.Duration
+}
+
+// WithProgress calls fn while operations are running, e.g. whenever a command is throttled and about once a
+// second while messages are scanned.
+// fn is called synchronously and should return quickly.
+func WithProgress(fn func(Progress)) Option {
+	return func(i *Inbox) {
+		i.progress = fn
+	}
+}
+
+// WithRateLimit limits the IMAP commands sent to cmdsPerSecond, to avoid being locked out by providers counting
+// commands per account. Without it commands are never delayed.
+func WithRateLimit(cmdsPerSecond float64) Option {
+	return func(i *Inbox) {
+		i.rateLimit = cmdsPerSecond
+	}
+}
+
+// WithBurst allows n commands at once before WithRateLimit kicks in. Defaults to 1.
+func WithBurst(n int) Option {
+	return func(i *Inbox) {
+		i.burst = n
+	}
+}
+
+// newLimiter returns the token bucket configured with WithRateLimit and WithBurst, nil without a limit.
+func newLimiter(cmdsPerSecond float64, burst int) *rate.Limiter {
+	if cmdsPerSecond <= 0 {
+		return nil
+	}
+
+	return rate.NewLimiter(rate.Limit(cmdsPerSecond), max(burst, 1))
+}
+
+// conn returns the client for sending the next command, after waiting for the rate limit. The command is
+// counted for WithBudget.
+func conn(b *Inbox) *client.Client {
+	b.usage.commands.Add(1)
+	if b.limiter == nil {
+		return currentClient(b)
+	}
+
+	if d := b.limiter.Reserve().Delay(); d > 0 {
+		time.Sleep(d)
+		b.waited += d
+		reportProgress(b, Progress{Waited: d, ETA: -1})
+	}
+
+	return currentClient(b)
+}
+
+// currentClient returns the client of the connection, which Reauthenticate and WithAutoReconnect replace.
+func currentClient(b *Inbox) *client.Client {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.client
+}
+
+// reportProgress calls the WithProgress callback, if any.
+func reportProgress(b *Inbox, p Progress) {
+	if b.progress == nil {
+		return
+	}
+
+	p.TotalWaited = b.waited
+	b.progress(p)
+}
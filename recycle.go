@@ -0,0 +1,147 @@
+package inbox
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+// RecycleFolder is the folder RecycleFromAddress moves messages to. It is created when needed.
+const RecycleFolder Folder = "go-inboxcleaner-recycle"
+
+// recycledKeywordPrefix starts the keyword recording the day a message was recycled, like "$Recycled-2024-03-01".
+// The INTERNALDATE is kept by MOVE, so it can't tell.
+const recycledKeywordPrefix = "$Recycled-"
+
+// RecycleFromAddress moves all messages in the folder sent from the given addresses to RecycleFolder instead of
+// deleting them, so they can be restored until EmptyRecycle removes them. It returns the number of moved messages.
+// Servers without MOVE return ErrCapabilityMissing, a copy and expunge could remove messages other clients flagged.
+func (b *Inbox) RecycleFromAddress(folder Folder, addr ...string) (int, error) {
+	defer operation(b)()
+	addr, err := normalizeAddresses(addr)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ensureFolder(b, RecycleFolder); err != nil {
+		return 0, err
+	}
+
+	mbox, err := selectFolder(b, folder)
+	if err != nil {
+		return 0, err
+	}
+
+	uids, err := matchingUIDs(b, b.matcher.FromAny(addr...))
+	if err != nil || len(uids) == 0 {
+		return 0, err
+	}
+
+	uidSet := new(imap.SeqSet)
+	uidSet.AddNum(uids...)
+	if skipMutation(b, "recycle", uidSet, "from", folder) {
+		return len(uids), nil
+	}
+
+	if err := requireCapability(b, "MOVE"); err != nil {
+		return 0, err
+	}
+
+	keyword := recycledKeywordPrefix + now(b).Format(time.DateOnly)
+	if keywordPermitted(mbox, keyword) {
+		if err := conn(b).UidStore(uidSet, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{keyword}, nil); err != nil {
+			return 0, err
+		}
+	} else {
+		log.Println(folder, "doesn't accept keywords, EmptyRecycle will fall back to the received date")
+	}
+
+	dest, err := serverFolder(b, RecycleFolder)
+	if err != nil {
+		return 0, err
+	}
+
+	invalidateSelected(b)
+	if err := conn(b).UidMove(uidSet, string(dest)); err != nil {
+		return 0, err
+	}
+
+	log.Println("Recycled", len(uids), "messages from", folder)
+	return len(uids), nil
+}
+
+// EmptyRecycle permanently deletes the messages recycled more than olderThan ago from RecycleFolder.
+// Messages without a record of the recycling day are judged by the time the server received them.
+func (b *Inbox) EmptyRecycle(olderThan time.Duration) (res DeleteResult, err error) {
+	defer operation(b)()
+	res = DeleteResult{Folder: RecycleFolder}
+	defer func(start time.Time) { observeDelete(b, start, &res, err) }(startDelete(b))
+
+	mbox, err := selectFolder(b, RecycleFolder)
+	if err != nil {
+		return res, err
+	}
+
+	cutoff := now(b).Add(-olderThan)
+	errChan := make(chan error, 1)
+	messages := make(chan *imap.Message, 10)
+	go func() {
+		errChan <- fetchAllMessages(mbox, b, messages, imap.FetchUid, imap.FetchFlags, imap.FetchInternalDate)
+	}()
+
+	delUIDs := new(imap.SeqSet)
+	for msg := range messages {
+		if recycledAt(msg).Before(cutoff) {
+			delUIDs.AddNum(msg.Uid)
+			res.Matched++
+		}
+	}
+	if err := <-errChan; err != nil {
+		return res, err
+	}
+	log.Println("Recycled messages to delete:", res.Matched)
+	if res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res)
+	return res, err
+}
+
+// recycledAt returns the day the message was recycled, or its INTERNALDATE without the keyword.
+func recycledAt(msg *imap.Message) time.Time {
+	for _, flag := range msg.Flags {
+		if !strings.HasPrefix(flag, recycledKeywordPrefix) {
+			continue
+		}
+
+		if day, err := time.ParseInLocation(time.DateOnly, strings.TrimPrefix(flag, recycledKeywordPrefix), time.Local); err == nil {
+			return day
+		}
+	}
+
+	return msg.InternalDate
+}
+
+// ensureFolder creates the folder unless it exists.
+func ensureFolder(b *Inbox, folder Folder) error {
+	name, err := serverFolder(b, folder)
+	if err != nil {
+		return err
+	}
+
+	mailboxes, err := listPattern(b, string(name))
+	if err != nil || len(mailboxes) > 0 {
+		return err
+	}
+
+	if skipMutation(b, "create folder", folder) {
+		return nil
+	}
+
+	log.Println("Creating folder", folder)
+	return conn(b).Create(string(name))
+}
@@ -0,0 +1,27 @@
+package inbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+)
+
+func TestRecycleWithoutMoveCapability(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "recycle me", time.Now())
+	s.addMessage(t, "Archive", "b@example.com", "deleted elsewhere", time.Now(), imap.DeletedFlag)
+	b := s.dial(t)
+	if supports(b, "MOVE") {
+		t.Skip("test server supports MOVE")
+	}
+
+	want := ErrCapabilityMissing{Capability: "MOVE"}
+	if _, err := b.RecycleFromAddress("Archive", "a@example.com"); !errors.Is(err, want) {
+		t.Errorf("RecycleFromAddress without MOVE: err %v, want %v", err, want)
+	}
+	if n := len(s.mailbox(t, "Archive").Messages); n != 2 {
+		t.Errorf("Archive holds %d messages, want both, including the one another client flagged", n)
+	}
+}
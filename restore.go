@@ -0,0 +1,35 @@
+package inbox
+
+import (
+	"log"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// RestoreAllInFolder removes the \Deleted flag from every message in the folder, so a pending expunge removes
+// nothing, and returns the number of restored messages.
+func (b *Inbox) RestoreAllInFolder(folder Folder) (int, error) {
+	defer operation(b)()
+	if _, err := selectFolder(b, folder); err != nil {
+		return 0, err
+	}
+
+	uids, err := searchUIDs(b, criteria.AlreadyDeleted())
+	if err != nil || len(uids) == 0 {
+		return 0, err
+	}
+
+	uidSet := new(imap.SeqSet)
+	uidSet.AddNum(uids...)
+	if skipMutation(b, "restore", uidSet, "in", folder) {
+		return len(uids), nil
+	}
+
+	if err := conn(b).UidStore(uidSet, imap.FormatFlagsOp(imap.RemoveFlags, true), []interface{}{imap.DeletedFlag}, nil); err != nil {
+		return 0, err
+	}
+
+	log.Println("Restored", len(uids), "messages in", folder)
+	return len(uids), nil
+}
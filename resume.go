@@ -0,0 +1,87 @@
+package inbox
+
+import (
+	"log"
+)
+
+// ResumeState records the progress of a multi-folder run like CleanAccount, ApplyRetention or
+// DeleteMessagesInFoldersFromAddress. It is updated after every folder and can be persisted as JSON by the caller.
+// Passing it to the same run again skips the completed folders.
+type ResumeState struct {
+	// RunID identifies the run the state belongs to.
+	RunID string `json:"runId"`
+	// Completed holds the results of the completed folders.
+	Completed map[Folder]DeleteResult `json:"completed"`
+	// Current is the folder being processed, empty between folders.
+	Current Folder `json:"current,omitempty"`
+}
+
+// NewResumeState returns an empty state for the run.
+func NewResumeState(runID string) *ResumeState {
+	return &ResumeState{RunID: runID, Completed: make(map[Folder]DeleteResult)}
+}
+
+// WithResumeState records the progress of multi-folder runs in state. save is called after every change of
+// state, so the caller can persist it; an error of save stops the run. save may be nil.
+func WithResumeState(state *ResumeState, save func(*ResumeState) error) Option {
+	return func(i *Inbox) {
+		if state.Completed == nil {
+			state.Completed = make(map[Folder]DeleteResult)
+		}
+		i.resume = state
+		i.saveResume = save
+	}
+}
+
+// saveResumeState hands the resume state to the caller.
+func saveResumeState(b *Inbox) error {
+	if b.saveResume == nil {
+		return nil
+	}
+
+	return b.saveResume(b.resume)
+}
+
+// resumeFolder runs fn for the folder while tracking it in the resume state. Completed folders return their recorded
+// result marked as Resumed. A folder interrupted in an earlier run is run again, the remaining messages are
+// re-derived by searching anew; with WithStateFile the pending UIDs of its interrupted batch are deleted first.
+func resumeFolder(b *Inbox, folder Folder, fn func(Folder) (DeleteResult, error)) (DeleteResult, error) {
+	if res, ok := b.resume.Completed[folder]; ok {
+		log.Println("Skipping", folder, "completed by run", b.resume.RunID)
+		res.Resumed = true
+		return res, nil
+	}
+
+	var pending DeleteResult
+	interrupted := b.resume.Current == folder
+	if interrupted && b.statePath != "" {
+		var err error
+		if pending, err = b.Resume(); err != nil {
+			return pending, err
+		}
+	}
+
+	b.resume.Current = folder
+	if err := saveResumeState(b); err != nil {
+		return DeleteResult{Folder: folder}, err
+	}
+
+	res, err := fn(folder)
+	res.Resumed = interrupted
+	if pending.Folder == folder {
+		res.Matched += pending.Matched
+		res.Deleted += pending.Deleted
+		res.ExpungedUIDs = append(pending.ExpungedUIDs, res.ExpungedUIDs...)
+	}
+	if err != nil {
+		return res, err
+	}
+	if b.truncated {
+		// The folder stays current, so the next run processes the rest of it.
+		return res, nil
+	}
+
+	b.resume.Completed[folder] = res
+	b.resume.Current = ""
+	return res, saveResumeState(b)
+}
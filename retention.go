@@ -0,0 +1,144 @@
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// ErrFolderNotFound is returned for folders which don't exist on the server.
+var ErrFolderNotFound = errors.New("inbox: folder not found")
+
+// RetentionAction is what happens to messages exceeding a RetentionRule.
+type RetentionAction int
+
+const (
+	// RetentionReport only reports the messages exceeding the rule (safe mode).
+	RetentionReport RetentionAction = iota
+	// RetentionTrash moves the messages to the trash folder. Servers without MOVE return ErrCapabilityMissing.
+	RetentionTrash
+	// RetentionExpunge removes the messages permanently.
+	RetentionExpunge
+)
+
+// RetentionRule describes how long messages are kept in a folder.
+type RetentionRule struct {
+	// MaxAge is the age after which messages are removed, measured from the time the server received them.
+	MaxAge      time.Duration
+	KeepFlagged bool
+	KeepUnread  bool
+	Action      RetentionAction
+}
+
+// criteria returns the criteria selecting the messages exceeding the rule.
+func (r RetentionRule) criteria() criteria.Criteria {
+	cs := []criteria.Criteria{criteria.OlderThan(r.MaxAge)}
+	if r.KeepFlagged {
+		cs = append(cs, criteria.WithoutFlags(imap.FlaggedFlag))
+	}
+	if r.KeepUnread {
+		cs = append(cs, criteria.WithFlags(imap.SeenFlag))
+	}
+
+	return criteria.And(cs...)
+}
+
+// RetentionPolicy holds the retention rule of every folder to clean.
+type RetentionPolicy map[Folder]RetentionRule
+
+// ApplyRetention applies every rule of the policy to its folder. Folders missing on the server are reported with
+// ErrFolderNotFound in the returned error, like any other failing folder they don't stop the remaining ones.
+// Messages moved to the trash are counted as Deleted. ctx is checked between folders and stops running fetches.
+func (b *Inbox) ApplyRetention(ctx context.Context, policy RetentionPolicy) (map[Folder]DeleteResult, error) {
+	defer operation(b)()
+	defer withContext(b, ctx)()
+
+	infos, err := b.ListFolderInfos()
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers can't be selected, a rule for one is reported like a missing folder.
+	var existing []Folder
+	for _, info := range infos {
+		if info.Selectable() {
+			existing = append(existing, info.Folder)
+		}
+	}
+
+	folders := make([]Folder, 0, len(policy))
+	for folder := range policy {
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i] < folders[j] })
+
+	return forEachFolder(b, folders, func(folder Folder) (DeleteResult, error) {
+		if err := ctx.Err(); err != nil {
+			return DeleteResult{Folder: folder}, err
+		}
+
+		name, err := serverFolder(b, folder)
+		if err != nil {
+			return DeleteResult{Folder: folder}, err
+		}
+
+		if !folderExists(existing, logicalFolder(b, string(name))) {
+			log.Println("Skipping missing folder", folder)
+			return DeleteResult{Folder: folder}, fmt.Errorf("%w: %s", ErrFolderNotFound, folder)
+		}
+
+		return applyRetentionRule(b, folder, policy[folder])
+	})
+}
+
+// applyRetentionRule applies the rule to the folder, sparing flagged and important messages as configured with
+// WithPreserveFlagged.
+func applyRetentionRule(b *Inbox, folder Folder, rule RetentionRule) (DeleteResult, error) {
+	res := DeleteResult{Folder: folder}
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	uids, err := matchingUIDs(b, rule.criteria())
+	if err != nil {
+		return res, err
+	}
+	uids, res.Preserved, err = preserve(b, uids)
+	if err != nil || len(uids) == 0 {
+		return res, err
+	}
+
+	crit := criteria.ByUIDs(uids...)
+	if rule.Action == RetentionTrash {
+		trash, err := trashFolder(b)
+		if err != nil {
+			return res, err
+		}
+
+		n, err := b.Move(folder, trash, crit)
+		res.Matched, res.Deleted = n, n
+		return res, err
+	}
+
+	preserved := res.Preserved
+	res, err = b.Delete(rule.Action == RetentionExpunge, folder, crit)
+	res.Preserved = preserved
+	return res, err
+}
+
+func folderExists(folders []Folder, folder Folder) bool {
+	for _, f := range folders {
+		if f == folder || strings.EqualFold(string(f), imap.InboxName) && strings.EqualFold(string(folder), imap.InboxName) {
+			return true
+		}
+	}
+
+	return false
+}
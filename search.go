@@ -0,0 +1,135 @@
+package inbox
+
+import (
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/commands"
+	"github.com/emersion/go-imap/responses"
+)
+
+// searchUIDs returns the UIDs of all candidates for crit in the selected folder.
+func searchUIDs(b *Inbox, crit criteria.Criteria) ([]uint32, error) {
+	if err := requireCriteria(b, crit); err != nil {
+		return nil, err
+	}
+
+	if criteria.MatchesNothing(crit) {
+		return nil, nil
+	}
+
+	if keys := criteria.RawSearchKeys(crit); keys != nil {
+		return uidSearchRaw(b, keys)
+	}
+
+	search := crit.Search()
+	if search == nil {
+		search = imap.NewSearchCriteria()
+	}
+
+	return conn(b).UidSearch(search)
+}
+
+// uidSearchRaw runs UID SEARCH with the given keys.
+func uidSearchRaw(b *Inbox, keys []interface{}) ([]uint32, error) {
+	cmd := &commands.Uid{Cmd: &imap.Command{Name: "SEARCH", Arguments: keys}}
+	res := new(responses.Search)
+	status, err := conn(b).Execute(cmd, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Ids, status.Err()
+}
+
+// searchKeys returns the formatted search keys of crit.
+func searchKeys(crit criteria.Criteria) []interface{} {
+	if keys := criteria.RawSearchKeys(crit); keys != nil {
+		return keys
+	}
+
+	search := crit.Search()
+	if search == nil {
+		search = imap.NewSearchCriteria()
+	}
+
+	return search.Format()
+}
+
+// resolveCriteria searches the exact operands of crit which Match can't decide, see criteria.Resolver.
+func resolveCriteria(b *Inbox, crit criteria.Criteria) (criteria.Criteria, error) {
+	return criteria.Resolve(crit, func(c criteria.Criteria) ([]uint32, error) {
+		return searchUIDs(b, c)
+	})
+}
+
+// findMessages calls fn for every message in the selected folder matching crit. Messages are fetched with the
+// items crit needs plus the given ones. fn returns false to stop, the remaining messages are drained silently.
+func findMessages(b *Inbox, crit criteria.Criteria, items []imap.FetchItem, fn func(*imap.Message) bool) error {
+	crit, err := resolveCriteria(b, crit)
+	if err != nil {
+		return err
+	}
+
+	uids, err := searchUIDs(b, crit)
+	if err != nil || len(uids) == 0 {
+		return err
+	}
+
+	startProgress(b, len(uids))
+	return fetchBatched(b, uids, mergeItems(crit.Items(), items), func(msg *imap.Message) bool {
+		advanceProgress(b, 1)
+		if !(crit.Exact() || crit.Match(msg)) {
+			return true
+		}
+
+		return fn(msg)
+	})
+}
+
+// fetchBatched fetches the messages with the given UIDs and calls fn for each of them. fn returns false to stop,
+// the remaining messages are drained silently. The fetch stops early between batches when the deadline of
+// SetDeadline passed or the budget of WithBudget is used up, which marks the running operation as truncated.
+func fetchBatched(b *Inbox, uids []uint32, items []imap.FetchItem, fn func(*imap.Message) bool) error {
+	for _, batch := range fetchBatches(b, uids) {
+		if stopEarly(b) {
+			return nil
+		}
+
+		uidSet := new(imap.SeqSet)
+		uidSet.AddNum(batch...)
+
+		errChan := make(chan error, 1)
+		messages := make(chan *imap.Message, 10)
+		go func() {
+			errChan <- uidFetch(b, uidSet, mergeItems(items), messages)
+		}()
+
+		stopped := false
+		err := consume(b, messages, errChan, func(msg *imap.Message) {
+			if !stopped {
+				stopped = !fn(msg)
+			}
+		})
+		if err != nil || stopped {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeItems returns the UID item and all given fetch items without duplicates.
+func mergeItems(lists ...[]imap.FetchItem) []imap.FetchItem {
+	items := []imap.FetchItem{imap.FetchUid}
+	seen := map[imap.FetchItem]bool{imap.FetchUid: true}
+	for _, list := range lists {
+		for _, item := range list {
+			if !seen[item] {
+				seen[item] = true
+				items = append(items, item)
+			}
+		}
+	}
+
+	return items
+}
@@ -0,0 +1,94 @@
+package inbox
+
+import (
+	"log"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// SentResult is the outcome of DeleteSentTo.
+type SentResult struct {
+	DeleteResult
+	// PerRecipient is the number of matched messages sent to every given recipient. A message sent to several of
+	// them counts for each.
+	PerRecipient map[string]int
+}
+
+// DeleteSentTo deletes the messages in the Sent folder of the account sent to one of the recipients, matched
+// against To, Cc and Bcc. Recipients may also be domain patterns.
+// When expunge is set to "false", no "\DELETED" flag is set (safe mode).
+func (b *Inbox) DeleteSentTo(expunge bool, recipients ...string) (res SentResult, err error) {
+	defer operation(b)()
+	recipients, err = normalizeAddresses(recipients)
+	if err != nil {
+		return res, err
+	}
+
+	folder, err := b.ResolveFolder(WellKnownSent)
+	if err != nil {
+		return res, err
+	}
+
+	res = SentResult{DeleteResult: DeleteResult{Folder: folder}, PerRecipient: make(map[string]int)}
+	defer func(start time.Time) { observeDelete(b, start, &res.DeleteResult, err) }(startDelete(b))
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	crit := b.matcher.RecipientAny(recipients...)
+	uids, err := searchUIDs(b, crit)
+	if err != nil || len(uids) == 0 {
+		return res, err
+	}
+
+	delUIDs := new(imap.SeqSet)
+	err = fetchBatched(b, uids, crit.Items(), func(msg *imap.Message) bool {
+		addrs := criteria.Recipients(msg)
+		matched := false
+		for _, recipient := range recipients {
+			for _, addr := range addrs {
+				if b.matcher.Match(recipient, addr) {
+					res.PerRecipient[recipient]++
+					matched = true
+					break
+				}
+			}
+		}
+
+		if matched {
+			log.Println("\t", criteria.DecodeHeader(msg.Envelope.Subject))
+			delUIDs.AddNum(msg.Uid)
+			res.Matched++
+		}
+		return true
+	})
+	if err != nil {
+		return res, err
+	}
+
+	res.Unmatched = unmatchedRecipients(recipients, res.PerRecipient)
+	log.Println("Sent messages to delete in", folder+":", res.Matched)
+
+	if !expunge || res.Matched == 0 {
+		return res, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	exp.apply(&res.DeleteResult)
+	return res, err
+}
+
+// unmatchedRecipients returns the recipients no message was sent to.
+func unmatchedRecipients(recipients []string, counts map[string]int) []string {
+	var unmatched []string
+	for _, r := range recipients {
+		if counts[r] == 0 {
+			unmatched = append(unmatched, r)
+		}
+	}
+
+	return unmatched
+}
@@ -0,0 +1,221 @@
+package inbox
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+)
+
+// testServer is an IMAP server backed by memory, listening on localhost with TLS.
+type testServer struct {
+	addr   string
+	user   backend.User
+	client *tls.Config
+	log    *syncBuffer
+	srv    *server.Server
+}
+
+// syncBuffer records the traffic of the server.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *syncBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+// newTestServer starts a server with the user "username" and password "password", whose INBOX holds the message
+// of the memory backend. be wraps the backend, e.g. to change the mailboxes it returns.
+func newTestServer(t testing.TB, wrap ...func(backend.Backend) backend.Backend) *testServer {
+	t.Helper()
+	return newConfiguredServer(t, nil, wrap...)
+}
+
+// newConfiguredServer is like newTestServer, but calls configure before the server accepts connections, e.g. to
+// enable further authentication mechanisms.
+func newConfiguredServer(t testing.TB, configure func(*testServer), wrap ...func(backend.Backend) backend.Backend) *testServer {
+	t.Helper()
+
+	var be backend.Backend = memory.New()
+	user, err := be.Login(nil, "username", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range wrap {
+		be = w(be)
+	}
+
+	cert, pool := testCertificate(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := server.New(be)
+	s := &testServer{addr: l.Addr().String(), user: user, client: &tls.Config{RootCAs: pool}, log: new(syncBuffer), srv: srv}
+	srv.Debug = s.log
+	srv.ErrorLog = discardLogger{}
+	if configure != nil {
+		configure(s)
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	return s
+}
+
+type discardLogger struct{}
+
+func (discardLogger) Printf(string, ...interface{}) {}
+func (discardLogger) Println(...interface{})        {}
+
+// testCertificate returns a self-signed certificate for 127.0.0.1 and a pool trusting it.
+func testCertificate(t testing.TB) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "go-imapcleaner test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// dial connects a new Inbox to the server, which is logged out at the end of the test.
+func (s *testServer) dial(t testing.TB, opts ...Option) *Inbox {
+	t.Helper()
+
+	opts = append([]Option{WithTLSConfig(s.client)}, opts...)
+	b, err := New(ImapProvider(s.addr), &Credentials{Username: "username", Password: "password"}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Logout() })
+
+	return b
+}
+
+// mailbox returns the mailbox of the backend, which is created if missing.
+func (s *testServer) mailbox(t testing.TB, name string) *memory.Mailbox {
+	t.Helper()
+
+	mbox, err := s.user.GetMailbox(name)
+	if err != nil {
+		if err := s.user.CreateMailbox(name); err != nil {
+			t.Fatal(err)
+		}
+		if mbox, err = s.user.GetMailbox(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return mbox.(*memory.Mailbox)
+}
+
+// addMessage appends a message from the sender with the subject to the folder and returns its UID.
+func (s *testServer) addMessage(t testing.TB, folder Folder, from, subject string, date time.Time, flags ...string) uint32 {
+	t.Helper()
+
+	raw := "From: " + from + "\r\n" +
+		"To: username@example.org\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: " + date.Format(time.RFC1123Z) + "\r\n" +
+		"Message-ID: <" + strings.ReplaceAll(subject, " ", ".") + "@example.org>\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		"Hello\r\n"
+
+	mbox := s.mailbox(t, string(folder))
+	if err := mbox.CreateMessage(flags, date, bytes.NewBufferString(raw)); err != nil {
+		t.Fatal(err)
+	}
+
+	return mbox.Messages[len(mbox.Messages)-1].Uid
+}
+
+// commands returns the names of the commands the clients sent since the last reset, like "UID STORE".
+func (s *testServer) commands() []string {
+	var names []string
+	for _, line := range strings.Split(s.log.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "*" || fields[0] == "+" {
+			continue
+		}
+		if imap.StatusRespType(strings.ToUpper(fields[1])) == imap.StatusRespOk ||
+			imap.StatusRespType(strings.ToUpper(fields[1])) == imap.StatusRespNo ||
+			imap.StatusRespType(strings.ToUpper(fields[1])) == imap.StatusRespBad {
+			continue
+		}
+
+		name := strings.ToUpper(fields[1])
+		if name == "UID" && len(fields) > 2 {
+			name += " " + strings.ToUpper(fields[2])
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// sent reports whether a command with the name was sent since the last reset.
+func (s *testServer) sent(name string) bool {
+	for _, cmd := range s.commands() {
+		if cmd == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,38 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+)
+
+// selectedCount returns the number of messages in the selected folder as the session knows it from the SELECT and
+// later EXISTS responses. No STATUS is sent, RFC 3501 advises against it for the selected folder. go-imap doesn't
+// decrement the count on EXPUNGE, snapshot subtracts the expunged messages itself.
+func selectedCount(b *Inbox) (int, bool) {
+	mbox := currentClient(b).Mailbox()
+	if mbox == nil {
+		return 0, false
+	}
+
+	return int(mbox.Messages), true
+}
+
+// snapshot records the message counts before and after a deletion in res and warns if they don't add up.
+// before is the count before, ok false if it is unknown. Without UIDPLUS, EXPUNGE also removes the messages other
+// clients flagged as deleted, so a difference is expected and no warning is given.
+func snapshot(b *Inbox, res *expungeResult, before int, ok bool) {
+	current, currentOK := selectedCount(b)
+	if !ok || !currentOK {
+		return
+	}
+
+	res.before, res.after = before, current-res.expunged
+	if !res.uidExpunge {
+		return
+	}
+
+	if expected := before - res.flagged; res.after != expected {
+		res.warnings = append(res.warnings, fmt.Sprintf("%d messages after the expunge, expected %d: another client changed the folder", res.after, expected))
+		log.Println("Warning:", res.warnings[len(res.warnings)-1])
+	}
+}
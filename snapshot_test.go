@@ -0,0 +1,36 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+func TestSnapshotWithoutUIDPlus(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	s.addMessage(t, "Archive", "b@example.com", "second", time.Now(), imap.DeletedFlag)
+	s.addMessage(t, "Archive", "c@example.com", "third", time.Now())
+	b := s.dial(t)
+	if supports(b, "UIDPLUS") {
+		t.Skip("the test server supports UIDPLUS")
+	}
+	s.log.Reset()
+
+	// The plain EXPUNGE also removes the message another client flagged as deleted.
+	res, err := b.Delete(true, "Archive", criteria.FromAny("a@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.sent("STATUS") {
+		t.Error("counting the selected folder sent STATUS")
+	}
+	if res.BeforeCount != 3 || res.AfterCount != 1 {
+		t.Errorf("counts = %d before and %d after, want 3 and 1", res.BeforeCount, res.AfterCount)
+	}
+	if len(res.Warnings) > 0 {
+		t.Errorf("Warnings = %v without UIDPLUS, want none", res.Warnings)
+	}
+}
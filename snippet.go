@@ -0,0 +1,240 @@
+package inbox
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"golang.org/x/net/html"
+)
+
+// snippetMinBytes is the least number of bytes fetched of a text part, HTML needs some room for the markup.
+const snippetMinBytes = 2048
+
+// WithSnippets adds the first maxChars characters of the text to summaries and plans. They are taken from the
+// text/plain part or, without one, from the text/html part with its markup removed. Only the beginning of the
+// part is fetched, without setting \Seen. Parts which can't be decoded give an empty snippet.
+func WithSnippets(maxChars int) Option {
+	return func(i *Inbox) {
+		i.snippetChars = maxChars
+	}
+}
+
+// snippetPart is the text part a snippet is taken from.
+type snippetPart struct {
+	path     []int
+	encoding string
+	charset  string
+	html     bool
+}
+
+// addSnippets sets the snippets of the summaries of messages in the selected folder, if enabled.
+func addSnippets(b *Inbox, summaries []MessageSummary) error {
+	uids := make([]uint32, len(summaries))
+	for i, s := range summaries {
+		uids[i] = s.UID
+	}
+
+	snippets, err := fetchSnippets(b, uids)
+	if err != nil {
+		return err
+	}
+
+	for i := range summaries {
+		summaries[i].Snippet = snippets[summaries[i].UID]
+	}
+
+	return nil
+}
+
+// fetchSnippets returns the snippets of the messages in the selected folder by UID. Nothing is fetched unless
+// snippets are enabled.
+func fetchSnippets(b *Inbox, uids []uint32) (map[uint32]string, error) {
+	if b.snippetChars <= 0 || len(uids) == 0 {
+		return nil, nil
+	}
+
+	all := new(imap.SeqSet)
+	all.AddNum(uids...)
+	parts := make(map[uint32]snippetPart)
+	err := fetchEach(b, all, []imap.FetchItem{imap.FetchBodyStructure}, func(msg *imap.Message) {
+		if part, ok := textPart(msg.BodyStructure); ok {
+			parts[msg.Uid] = part
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Messages sharing the path of their text part are fetched together.
+	paths := make(map[string][]int)
+	byPath := make(map[string]*imap.SeqSet)
+	for uid, part := range parts {
+		key := fmt.Sprint(part.path)
+		if byPath[key] == nil {
+			paths[key] = part.path
+			byPath[key] = new(imap.SeqSet)
+		}
+		byPath[key].AddNum(uid)
+	}
+
+	snippets := make(map[uint32]string, len(parts))
+	for key, set := range byPath {
+		section := &imap.BodySectionName{
+			BodyPartName: imap.BodyPartName{Path: paths[key]},
+			Peek:         true,
+			Partial:      []int{0, max(snippetMinBytes, 4*b.snippetChars)},
+		}
+
+		err := fetchEach(b, set, []imap.FetchItem{section.FetchItem()}, func(msg *imap.Message) {
+			body := msg.GetBody(section)
+			if body == nil {
+				return
+			}
+
+			raw, err := io.ReadAll(body)
+			if err != nil {
+				return
+			}
+			snippets[msg.Uid] = decodeSnippet(raw, parts[msg.Uid], b.snippetChars)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return snippets, nil
+}
+
+// textPart returns the text/plain part of the body, or the text/html part if there is none. Attachments are
+// skipped.
+func textPart(bs *imap.BodyStructure) (snippetPart, bool) {
+	if bs == nil {
+		return snippetPart{}, false
+	}
+
+	var plain, htmlPart *snippetPart
+	bs.Walk(func(path []int, part *imap.BodyStructure) bool {
+		if len(part.Parts) > 0 || !strings.EqualFold(part.MIMEType, "text") ||
+			strings.EqualFold(part.Disposition, "attachment") {
+			return true
+		}
+
+		p := &snippetPart{path: path, encoding: part.Encoding, charset: part.Params["charset"]}
+		switch strings.ToLower(part.MIMESubType) {
+		case "plain":
+			if plain == nil {
+				plain = p
+			}
+		case "html":
+			if htmlPart == nil {
+				p.html = true
+				htmlPart = p
+			}
+		}
+
+		return plain == nil
+	})
+
+	switch {
+	case plain != nil:
+		return *plain, true
+	case htmlPart != nil:
+		return *htmlPart, true
+	}
+
+	return snippetPart{}, false
+}
+
+// decodeSnippet decodes the beginning of a text part and returns its first maxChars characters with the
+// whitespace collapsed. Undecodable or binary content gives an empty snippet.
+func decodeSnippet(raw []byte, part snippetPart, maxChars int) string {
+	switch strings.ToLower(part.encoding) {
+	case "base64":
+		// The part is cut off, only complete groups of four characters can be decoded.
+		clean := bytes.Join(bytes.Fields(raw), nil)
+		clean = clean[:len(clean)-len(clean)%4]
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(clean)))
+		n, err := base64.StdEncoding.Decode(decoded, clean)
+		if err != nil {
+			return ""
+		}
+		raw = decoded[:n]
+	case "quoted-printable":
+		// A cut off escape at the end fails the read, the text before it is kept.
+		decoded, _ := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(raw)))
+		raw = decoded
+	}
+
+	if part.charset != "" {
+		r, err := criteria.CharsetReader(part.charset, bytes.NewReader(raw))
+		if err != nil {
+			return ""
+		}
+		if raw, err = io.ReadAll(r); err != nil {
+			return ""
+		}
+	}
+
+	// The cut may have split the last character.
+	for i := 0; i < utf8.UTFMax && len(raw) > 0 && !utf8.Valid(raw); i++ {
+		raw = raw[:len(raw)-1]
+	}
+	if !utf8.Valid(raw) || bytes.IndexByte(raw, 0) >= 0 {
+		return ""
+	}
+
+	text := string(raw)
+	if part.html {
+		text = htmlText(text)
+	}
+
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) > maxChars {
+		text = string([]rune(text)[:maxChars])
+	}
+
+	return text
+}
+
+// htmlText returns the text of an HTML document without its markup, scripts and styles.
+func htmlText(s string) string {
+	var sb strings.Builder
+	skip := 0
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return sb.String()
+		case html.StartTagToken:
+			if name, _ := z.TagName(); isHiddenElement(name) {
+				skip++
+			}
+		case html.EndTagToken:
+			if name, _ := z.TagName(); isHiddenElement(name) && skip > 0 {
+				skip--
+			}
+		case html.TextToken:
+			if skip == 0 {
+				sb.Write(z.Text())
+				sb.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// isHiddenElement reports whether the text of the element isn't shown.
+func isHiddenElement(name []byte) bool {
+	switch string(name) {
+	case "script", "style", "head", "title":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,234 @@
+package inbox
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/commands"
+	"github.com/emersion/go-imap/responses"
+)
+
+// SortKey is the field messages are ordered by.
+type SortKey int
+
+const (
+	// SortDate orders by the Date header.
+	SortDate SortKey = iota
+	// SortArrival orders by the time the server received the message.
+	SortArrival
+	// SortSize orders by RFC822.SIZE.
+	SortSize
+	// SortFrom orders by the address of the first sender.
+	SortFrom
+	// SortSubject orders by the subject without reply and forward prefixes.
+	SortSubject
+)
+
+// sortCriteria are the SORT criteria of RFC 5256 for every key.
+var sortCriteria = map[SortKey]string{
+	SortDate:    "DATE",
+	SortArrival: "ARRIVAL",
+	SortSize:    "SIZE",
+	SortFrom:    "FROM",
+	SortSubject: "SUBJECT",
+}
+
+// SortOrder orders listed messages by Key, descending with Reverse.
+type SortOrder struct {
+	Key     SortKey
+	Reverse bool
+}
+
+// FindSorted is like Find, but returns the UIDs in the given order. Servers advertising SORT order them,
+// otherwise the fields needed are fetched and sorted by the package.
+func (b *Inbox) FindSorted(folder Folder, crit criteria.Criteria, order SortOrder) ([]uint32, error) {
+	defer operation(b)()
+	if _, err := examineFolder(b, folder); err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	err := findSorted(b, crit, order, nil, func(msg *imap.Message) {
+		uids = append(uids, msg.Uid)
+	})
+
+	return uids, err
+}
+
+// FindSummariesSorted is like FindSummaries, but returns the summaries in the given order.
+func (b *Inbox) FindSummariesSorted(folder Folder, crit criteria.Criteria, order SortOrder) ([]MessageSummary, error) {
+	defer operation(b)()
+	if _, err := examineFolder(b, folder); err != nil {
+		return nil, err
+	}
+
+	var summaries []MessageSummary
+	err := findSorted(b, crit, order, summaryFetchItems(b), func(msg *imap.Message) {
+		summaries = append(summaries, newSummary(folder, msg))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return summaries, addSnippets(b, summaries)
+}
+
+// findSorted calls fn in the given order for every message in the selected folder matching crit.
+func findSorted(b *Inbox, crit criteria.Criteria, order SortOrder, items []imap.FetchItem, fn func(*imap.Message)) error {
+	crit, err := resolveCriteria(b, crit)
+	if err != nil {
+		return err
+	}
+
+	serverSorted := supports(b, "SORT")
+
+	var uids []uint32
+	if serverSorted {
+		uids, err = uidSort(b, crit, order)
+	} else {
+		uids, err = searchUIDs(b, crit)
+	}
+	if err != nil || len(uids) == 0 {
+		return err
+	}
+
+	var sortItems []imap.FetchItem
+	if !serverSorted {
+		sortItems = []imap.FetchItem{imap.FetchEnvelope, imap.FetchInternalDate, imap.FetchRFC822Size}
+	}
+
+	var msgs []*imap.Message
+	uidSet := new(imap.SeqSet)
+	uidSet.AddNum(uids...)
+	err = fetchEach(b, uidSet, mergeItems(crit.Items(), items, sortItems), func(msg *imap.Message) {
+		if crit.Exact() || crit.Match(msg) {
+			msgs = append(msgs, msg)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if serverSorted {
+		pos := make(map[uint32]int, len(uids))
+		for i, uid := range uids {
+			pos[uid] = i
+		}
+		sort.Slice(msgs, func(i, j int) bool { return pos[msgs[i].Uid] < pos[msgs[j].Uid] })
+	} else {
+		sort.SliceStable(msgs, func(i, j int) bool { return lessMessage(msgs[i], msgs[j], order) })
+	}
+
+	for _, msg := range msgs {
+		fn(msg)
+	}
+
+	return nil
+}
+
+// uidSort runs UID SORT (RFC 5256) with the search keys of crit.
+func uidSort(b *Inbox, crit criteria.Criteria, order SortOrder) ([]uint32, error) {
+	if err := requireCriteria(b, crit); err != nil {
+		return nil, err
+	}
+
+	if criteria.MatchesNothing(crit) {
+		return nil, nil
+	}
+
+	var program []interface{}
+	if order.Reverse {
+		program = append(program, imap.RawString("REVERSE"))
+	}
+	program = append(program, imap.RawString(sortCriteria[order.Key]))
+
+	args := append([]interface{}{program, imap.RawString("UTF-8")}, searchKeys(crit)...)
+	cmd := &commands.Uid{Cmd: &imap.Command{Name: "SORT", Arguments: args}}
+
+	var uids []uint32
+	handler := responses.HandlerFunc(func(resp imap.Resp) error {
+		name, fields, ok := imap.ParseNamedResp(resp)
+		if !ok || name != "SORT" {
+			return responses.ErrUnhandled
+		}
+
+		for _, f := range fields {
+			if uid, err := imap.ParseNumber(f); err == nil {
+				uids = append(uids, uid)
+			}
+		}
+
+		return nil
+	})
+
+	status, err := conn(b).Execute(cmd, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	return uids, status.Err()
+}
+
+// lessMessage orders messages like the SORT extension would, ties by UID.
+func lessMessage(a, b *imap.Message, order SortOrder) bool {
+	c := compareMessages(a, b, order.Key)
+	if c == 0 {
+		return a.Uid < b.Uid
+	}
+
+	return c < 0 != order.Reverse
+}
+
+// compareMessages compares two messages by key, returning -1, 0 or 1.
+func compareMessages(a, b *imap.Message, key SortKey) int {
+	switch key {
+	case SortDate:
+		return compareInt(sortDate(a), sortDate(b))
+	case SortArrival:
+		return compareInt(a.InternalDate.Unix(), b.InternalDate.Unix())
+	case SortSize:
+		return compareInt(int64(a.Size), int64(b.Size))
+	case SortFrom:
+		return strings.Compare(sortFrom(a), sortFrom(b))
+	default:
+		return strings.Compare(sortSubject(a), sortSubject(b))
+	}
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// sortDate is the Date header, or the internal date for messages without one, as RFC 5256 defines.
+func sortDate(msg *imap.Message) int64 {
+	if msg.Envelope != nil && !msg.Envelope.Date.IsZero() {
+		return msg.Envelope.Date.Unix()
+	}
+
+	return msg.InternalDate.Unix()
+}
+
+func sortFrom(msg *imap.Message) string {
+	if msg.Envelope == nil || len(msg.Envelope.From) == 0 {
+		return ""
+	}
+
+	return strings.ToLower(msg.Envelope.From[0].Address())
+}
+
+func sortSubject(msg *imap.Message) string {
+	if msg.Envelope == nil {
+		return ""
+	}
+
+	return normalizeSubject(criteria.DecodeHeader(msg.Envelope.Subject))
+}
@@ -0,0 +1,164 @@
+package inbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/emersion/go-imap"
+)
+
+// stateBatchSize is the number of messages deleted between two state file updates.
+const stateBatchSize = 500
+
+// WithStateFile persists the progress of permanent deletions to path. Messages are then deleted in batches and
+// the file always holds the UIDs not expunged yet, so an interrupted run can be finished with Resume.
+// The file is removed once a deletion completes.
+func WithStateFile(path string) Option {
+	return func(i *Inbox) {
+		i.statePath = path
+	}
+}
+
+// deleteState is the progress of a deletion as stored in the state file.
+type deleteState struct {
+	Folder      Folder `json:"folder"`
+	UIDValidity uint32 `json:"uidValidity"`
+	// LastUID is the highest UID of the last expunged batch.
+	LastUID uint32 `json:"lastUid"`
+	// Pending is the UID set still to be deleted.
+	Pending string `json:"pending"`
+}
+
+func writeState(path string, state deleteState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes to a temporary file first, so an interruption never leaves a truncated file behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func readState(path string) (deleteState, error) {
+	var state deleteState
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("inbox: invalid state file %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+// deleteInBatches deletes the messages of the selected folder in batches and records the pending UIDs in the
+// state file before each batch.
+func deleteInBatches(b *Inbox, delUIDs *imap.SeqSet) (expungeResult, error) {
+	mbox := currentClient(b).Mailbox()
+	if mbox == nil {
+		return expungeResult{}, errors.New("inbox: no folder selected")
+	}
+
+	uids := seqSetNums(delUIDs)
+	state := deleteState{Folder: Folder(mbox.Name), UIDValidity: mbox.UidValidity}
+	res := expungeResult{uidExpunge: true}
+	for start := 0; start < len(uids); start += stateBatchSize {
+		end := min(start+stateBatchSize, len(uids))
+
+		pending := new(imap.SeqSet)
+		pending.AddNum(uids[start:]...)
+		state.Pending = pending.String()
+		if err := writeState(b.statePath, state); err != nil {
+			return res, err
+		}
+
+		batch := new(imap.SeqSet)
+		batch.AddNum(uids[start:end]...)
+		batchRes, err := storeAndExpunge(b, batch)
+		res.deleted += batchRes.deleted
+		res.uids = append(res.uids, batchRes.uids...)
+		res.flagged += batchRes.flagged
+		res.expunged += batchRes.expunged
+		res.uidExpunge = res.uidExpunge && batchRes.uidExpunge
+		if err != nil {
+			return res, err
+		}
+
+		state.LastUID = uids[end-1]
+	}
+
+	if err := os.Remove(b.statePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return res, err
+	}
+
+	return res, nil
+}
+
+// seqSetNums returns the numbers in set in ascending order. Ranges ending with "*" are not supported.
+func seqSetNums(set *imap.SeqSet) []uint32 {
+	var nums []uint32
+	for _, seq := range set.Set {
+		for n := seq.Start; n <= seq.Stop && n != 0; n++ {
+			nums = append(nums, n)
+		}
+	}
+
+	return nums
+}
+
+// Resume finishes a deletion interrupted while WithStateFile was set. It deletes the messages still pending in
+// the state file, the matching is not repeated. Without a state file there is nothing to resume and the
+// result is empty. A state file is discarded if the folder's UIDVALIDITY changed since it was written.
+func (b *Inbox) Resume() (res DeleteResult, err error) {
+	defer operation(b)()
+	if b.statePath == "" {
+		return res, errors.New("inbox: Resume needs WithStateFile")
+	}
+
+	state, err := readState(b.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Folder = state.Folder
+
+	mbox, err := selectFolder(b, state.Folder)
+	if err != nil {
+		return res, err
+	}
+
+	if mbox.UidValidity != state.UIDValidity {
+		os.Remove(b.statePath)
+		return res, fmt.Errorf("inbox: UIDVALIDITY of %s changed, discarded state file %s", state.Folder, b.statePath)
+	}
+
+	pending, err := imap.ParseSeqSet(state.Pending)
+	if err != nil {
+		return res, fmt.Errorf("inbox: invalid pending UIDs in state file %s: %w", b.statePath, err)
+	}
+
+	res.Matched = len(seqSetNums(pending))
+	if skipMutation(b, "resume deletion of", pending, "in", state.Folder) {
+		return res, nil
+	}
+	log.Println("Resuming deletion of", res.Matched, "messages in", state.Folder, "after UID", state.LastUID)
+	exp, err := deleteInBatches(b, pending)
+	exp.apply(&res)
+	return res, err
+}
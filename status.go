@@ -0,0 +1,72 @@
+package inbox
+
+import (
+	"github.com/emersion/go-imap"
+)
+
+// FolderCounts are the message counts of a folder.
+type FolderCounts struct {
+	Messages int
+	Unseen   int
+}
+
+// MessageCount returns the number of messages in the folder. It uses STATUS, so the folder isn't selected.
+func (b *Inbox) MessageCount(folder Folder) (int, error) {
+	defer operation(b)()
+	counts, err := b.FolderCounts(folder)
+	return counts.Messages, err
+}
+
+// FolderCounts returns the number of all and of unseen messages in the folder. It uses STATUS, so the folder
+// isn't selected.
+func (b *Inbox) FolderCounts(folder Folder) (FolderCounts, error) {
+	defer operation(b)()
+	if err := checkOpen(b); err != nil {
+		return FolderCounts{}, err
+	}
+
+	name, err := serverFolder(b, folder)
+	if err != nil {
+		return FolderCounts{}, err
+	}
+
+	status, err := folderStatus(b, string(name), imap.StatusMessages, imap.StatusUnseen)
+	if err != nil {
+		return FolderCounts{}, err
+	}
+
+	return FolderCounts{Messages: int(status.Messages), Unseen: int(status.Unseen)}, nil
+}
+
+// messageCount returns the number of messages in the mailbox with STATUS.
+func messageCount(b *Inbox, name string) (uint32, error) {
+	status, err := folderStatus(b, name, imap.StatusMessages)
+	if err != nil {
+		return 0, err
+	}
+
+	return status.Messages, nil
+}
+
+// folderStatus runs STATUS for the mailbox with its name on the server. The counts of the selected mailbox are
+// taken from the session instead, as RFC 3501 advises against STATUS on it. The session doesn't count the EXPUNGE
+// responses down, so after an expunge the mailbox is selected again at the same level for a fresh count, see
+// invalidateSelected.
+func folderStatus(b *Inbox, name string, items ...imap.StatusItem) (*imap.MailboxStatus, error) {
+	if mbox := currentClient(b).Mailbox(); mbox != nil && mbox.Name == name && !needsUnseen(items) {
+		return selectName(b, name, mbox.ReadOnly)
+	}
+
+	return conn(b).Status(name, items)
+}
+
+// needsUnseen reports whether the items ask for UNSEEN, which the session doesn't count.
+func needsUnseen(items []imap.StatusItem) bool {
+	for _, item := range items {
+		if item == imap.StatusUnseen {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,27 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+func TestMessageCountAfterDelete(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	s.addMessage(t, "Archive", "b@example.com", "second", time.Now())
+	b := s.dial(t)
+
+	if _, err := b.Delete(true, "Archive", criteria.FromAny("a@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := messageCount(b, "Archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("messageCount after deleting one of two messages = %d, want 1", n)
+	}
+}
@@ -0,0 +1,64 @@
+package inbox
+
+import (
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// summaryItems are the fetch items needed to build a MessageSummary.
+var summaryItems = []imap.FetchItem{imap.FetchUid, imap.FetchEnvelope, imap.FetchFlags, imap.FetchInternalDate, imap.FetchRFC822Size}
+
+// summaryFetchItems returns summaryItems, plus the Gmail labels when the server supports them.
+func summaryFetchItems(b *Inbox) []imap.FetchItem {
+	if supports(b, criteria.GmailCapability) {
+		return append(summaryItems[:len(summaryItems):len(summaryItems)], criteria.GmailLabelsItem)
+	}
+
+	return summaryItems
+}
+
+// MessageSummary describes a message without its body.
+type MessageSummary struct {
+	Folder    Folder
+	UID       uint32
+	MessageID string
+	// FromName is the display name of the first sender, FromAddress its address.
+	FromName    string
+	FromAddress string
+	Subject     string
+	// Date is the Date header of the message, InternalDate the time the server received it.
+	Date         time.Time
+	InternalDate time.Time
+	Size         uint32
+	Flags        []string
+	// Labels are the Gmail labels, only set on servers advertising X-GM-EXT-1.
+	Labels []string
+	// Snippet is the beginning of the text, only set with WithSnippets.
+	Snippet string
+}
+
+// newSummary builds the summary of a message fetched with summaryItems.
+func newSummary(folder Folder, msg *imap.Message) MessageSummary {
+	s := MessageSummary{
+		Folder:       folder,
+		UID:          msg.Uid,
+		InternalDate: msg.InternalDate,
+		Size:         msg.Size,
+		Flags:        msg.Flags,
+		Labels:       criteria.GmailLabels(msg),
+	}
+
+	if env := msg.Envelope; env != nil {
+		s.MessageID = env.MessageId
+		s.Subject = criteria.DecodeHeader(env.Subject)
+		s.Date = env.Date
+		if len(env.From) > 0 {
+			s.FromName = criteria.DecodeHeader(env.From[0].PersonalName)
+			s.FromAddress = env.From[0].Address()
+		}
+	}
+
+	return s
+}
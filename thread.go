@@ -0,0 +1,288 @@
+package inbox
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/responses"
+)
+
+// WithIncludeThread extends every sender based deletion to the whole conversations of the matching messages.
+// Threads are built within the folder being cleaned only, with THREAD=REFERENCES when the server supports it and
+// from Message-ID, In-Reply-To and References otherwise.
+func WithIncludeThread(include bool) Option {
+	return func(i *Inbox) {
+		i.includeThread = include
+	}
+}
+
+var referencesSection = criteria.HeaderSection("References")
+
+var messageIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+// includeThreads adds the remaining messages of the threads in delUIDs to it and counts them in res.Thread.
+func includeThreads(b *Inbox, res *DeleteResult, delUIDs *imap.SeqSet) error {
+	if !b.includeThread || delUIDs.Empty() {
+		return nil
+	}
+
+	threads, err := folderThreads(b)
+	if err != nil {
+		return err
+	}
+
+	var added []uint32
+	for _, thread := range threads {
+		hit := false
+		for _, uid := range thread {
+			hit = hit || delUIDs.Contains(uid)
+		}
+		if !hit {
+			continue
+		}
+
+		for _, uid := range thread {
+			if !delUIDs.Contains(uid) {
+				added = append(added, uid)
+			}
+		}
+	}
+
+	if len(added) > 0 {
+		log.Println("Including", len(added), "more messages of the same threads in", res.Folder)
+		delUIDs.AddNum(added...)
+	}
+	res.Thread = len(added)
+
+	return nil
+}
+
+// folderThreads returns the UIDs of every thread in the selected folder.
+func folderThreads(b *Inbox) ([][]uint32, error) {
+	if supports(b, "THREAD=REFERENCES") {
+		return serverThreads(b)
+	}
+
+	return clientThreads(b)
+}
+
+// serverThreads runs UID THREAD REFERENCES (RFC 5256) and flattens every thread tree.
+func serverThreads(b *Inbox) ([][]uint32, error) {
+	cmd := &imap.Command{
+		Name:      "UID",
+		Arguments: []interface{}{imap.RawString("THREAD"), imap.RawString("REFERENCES"), imap.RawString("UTF-8"), imap.RawString("ALL")},
+	}
+
+	var threads [][]uint32
+	handler := responses.HandlerFunc(func(resp imap.Resp) error {
+		name, fields, ok := imap.ParseNamedResp(resp)
+		if !ok || name != "THREAD" {
+			return responses.ErrUnhandled
+		}
+
+		for _, f := range fields {
+			threads = append(threads, flattenThread(f, nil))
+		}
+
+		return nil
+	})
+
+	status, err := conn(b).Execute(cmd, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	return threads, status.Err()
+}
+
+func flattenThread(f interface{}, uids []uint32) []uint32 {
+	if list, ok := f.([]interface{}); ok {
+		for _, item := range list {
+			uids = flattenThread(item, uids)
+		}
+		return uids
+	}
+
+	if uid, err := imap.ParseNumber(f); err == nil {
+		uids = append(uids, uid)
+	}
+
+	return uids
+}
+
+// clientThreads groups the messages of the selected folder by their Message-ID, In-Reply-To and References.
+func clientThreads(b *Inbox) ([][]uint32, error) {
+	t := newThreader()
+	all := new(imap.SeqSet)
+	all.AddRange(1, 0)
+	err := fetchEach(b, all, []imap.FetchItem{imap.FetchEnvelope, referencesSection.FetchItem()}, func(msg *imap.Message) {
+		t.add(msg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.threads(), nil
+}
+
+// threader groups messages into threads with a union-find over their Message-IDs.
+type threader struct {
+	parent map[string]string
+	msgIDs map[uint32]string
+}
+
+func newThreader() *threader {
+	return &threader{parent: make(map[string]string), msgIDs: make(map[uint32]string)}
+}
+
+func (t *threader) find(id string) string {
+	p, ok := t.parent[id]
+	if !ok || p == id {
+		t.parent[id] = id
+		return id
+	}
+
+	root := t.find(p)
+	t.parent[id] = root
+	return root
+}
+
+func (t *threader) union(a, b string) {
+	t.parent[t.find(a)] = t.find(b)
+}
+
+// add links a message fetched with ENVELOPE and referencesSection to the messages it refers to and returns its ID.
+func (t *threader) add(msg *imap.Message) string {
+	if msg.Envelope == nil {
+		return ""
+	}
+
+	id := msg.Envelope.MessageId
+	if id == "" {
+		// Without Message-ID the message is its own thread.
+		id = fmt.Sprint("uid:", msg.Uid)
+	}
+	t.msgIDs[msg.Uid] = id
+	t.find(id)
+
+	related := messageIDPattern.FindAllString(msg.Envelope.InReplyTo, -1)
+	for _, refs := range criteria.HeaderValues(msg, referencesSection, "References") {
+		related = append(related, messageIDPattern.FindAllString(refs, -1)...)
+	}
+	for _, ref := range related {
+		t.union(ref, id)
+	}
+
+	return id
+}
+
+// threads returns the UIDs of every thread.
+func (t *threader) threads() [][]uint32 {
+	byRoot := make(map[string][]uint32)
+	for uid, id := range t.msgIDs {
+		root := t.find(id)
+		byRoot[root] = append(byRoot[root], uid)
+	}
+
+	threads := make([][]uint32, 0, len(byRoot))
+	for _, thread := range byRoot {
+		threads = append(threads, thread)
+	}
+
+	return threads
+}
+
+var subjectPrefixPattern = regexp.MustCompile(`(?i)^\s*((re|fwd?|aw|wg|sv|antw)(\[\d+\])?\s*:\s*)+`)
+
+// normalizeSubject strips reply and forward prefixes, so all messages of a conversation share the subject.
+func normalizeSubject(subject string) string {
+	return strings.ToLower(strings.TrimSpace(subjectPrefixPattern.ReplaceAllString(subject, "")))
+}
+
+// subjectKey groups messages which don't reference each other, like notifications, by the subject without reply
+// prefixes and their participants, so unrelated conversations sharing a subject like "Meeting" stay apart.
+// It is empty for messages without subject.
+func subjectKey(env *imap.Envelope) string {
+	subject := normalizeSubject(criteria.DecodeHeader(env.Subject))
+	if subject == "" {
+		return ""
+	}
+
+	seen := make(map[string]bool)
+	var participants []string
+	for _, list := range [][]*imap.Address{env.From, env.To, env.Cc} {
+		for _, addr := range list {
+			if a := strings.ToLower(addr.Address()); a != "" && !seen[a] {
+				seen[a] = true
+				participants = append(participants, a)
+			}
+		}
+	}
+	sort.Strings(participants)
+
+	return "subject:" + subject + "\x00" + strings.Join(participants, ",")
+}
+
+// KeepLatestPerThread keeps only the newest message of every thread in the folder and returns the number of other
+// messages. Threads are grouped by Message-ID, In-Reply-To and References, and by the subject without reply
+// prefixes and the participants for notifications which don't reference each other, see subjectKey.
+// When expunge is set to "false", nothing is deleted.
+func (b *Inbox) KeepLatestPerThread(folder Folder, expunge bool) (int, error) {
+	defer operation(b)()
+	mbox, err := selectFolder(b, folder)
+	if err != nil || mbox.Messages == 0 {
+		return 0, err
+	}
+
+	t := newThreader()
+	received := make(map[uint32]time.Time)
+	all := new(imap.SeqSet)
+	all.AddRange(1, 0)
+	items := []imap.FetchItem{imap.FetchEnvelope, imap.FetchInternalDate, referencesSection.FetchItem()}
+	err = fetchEach(b, all, items, func(msg *imap.Message) {
+		id := t.add(msg)
+		if id == "" {
+			return
+		}
+
+		if key := subjectKey(msg.Envelope); key != "" {
+			t.union(key, id)
+		}
+		received[msg.Uid] = msg.InternalDate
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delUIDs := new(imap.SeqSet)
+	removed := 0
+	for _, thread := range t.threads() {
+		latest := thread[0]
+		for _, uid := range thread[1:] {
+			if received[uid].After(received[latest]) || received[uid].Equal(received[latest]) && uid > latest {
+				latest = uid
+			}
+		}
+
+		for _, uid := range thread {
+			if uid != latest {
+				delUIDs.AddNum(uid)
+				removed++
+			}
+		}
+	}
+
+	log.Println("Messages to delete in", folder+":", removed, "older messages of their thread")
+	if !expunge || removed == 0 {
+		return removed, nil
+	}
+
+	exp, err := deleteMessagesPermanently(b, delUIDs)
+	return exp.deleted, err
+}
@@ -0,0 +1,40 @@
+package inbox
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/emersion/go-imap"
+)
+
+func envelope(subject string, from, to string) *imap.Envelope {
+	addr := func(a string) []*imap.Address {
+		local, host, _ := strings.Cut(a, "@")
+		return []*imap.Address{{MailboxName: local, HostName: host}}
+	}
+
+	return &imap.Envelope{Subject: subject, From: addr(from), To: addr(to)}
+}
+
+func TestSubjectKey(t *testing.T) {
+	meeting := subjectKey(envelope("Meeting", "alice@example.com", "username@example.org"))
+	tests := []struct {
+		name string
+		env  *imap.Envelope
+		same bool
+	}{
+		{"other sender", envelope("Meeting", "bob@example.com", "username@example.org"), false},
+		{"reply", envelope("Re: Meeting", "username@example.org", "Alice@example.com"), true},
+		{"other subject", envelope("Lunch", "alice@example.com", "username@example.org"), false},
+	}
+
+	for _, tt := range tests {
+		if got := subjectKey(tt.env) == meeting; got != tt.same {
+			t.Errorf("%s: same key as the original %v, want %v", tt.name, got, tt.same)
+		}
+	}
+
+	if key := subjectKey(envelope("Re: ", "alice@example.com", "username@example.org")); key != "" {
+		t.Errorf("subjectKey without subject = %q, want empty", key)
+	}
+}
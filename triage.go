@@ -0,0 +1,95 @@
+package inbox
+
+import (
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+	"github.com/emersion/go-imap"
+)
+
+// TriageAction is the decision made for a single message during Triage.
+type TriageAction int
+
+const (
+	// Keep leaves the message untouched.
+	Keep TriageAction = iota
+	// Delete removes the message permanently.
+	Delete
+	// Trash moves the message to the trash folder.
+	Trash
+	// Stop ends the triage, decisions made so far are still applied.
+	Stop
+)
+
+// TriageResult summarizes a triage run.
+type TriageResult struct {
+	Kept    int
+	Deleted int
+	Trashed int
+	// Stopped is true when the triage was ended with Stop.
+	Stopped bool
+}
+
+// Triage calls decide for every message in the folder matching crit. Decisions are collected and applied
+// in batches at the end: one move to the trash folder and one delete for all messages. On servers without MOVE,
+// the first Trash decision ends the triage with ErrCapabilityMissing and nothing is applied.
+func (b *Inbox) Triage(folder Folder, crit criteria.Criteria, decide func(MessageSummary) TriageAction) (TriageResult, error) {
+	defer operation(b)()
+	var res TriageResult
+
+	if _, err := selectFolder(b, folder); err != nil {
+		return res, err
+	}
+
+	moveErr := requireCapability(b, "MOVE")
+	var trashErr error
+	delUIDs := new(imap.SeqSet)
+	trashUIDs := new(imap.SeqSet)
+	err := findMessages(b, crit, summaryFetchItems(b), func(msg *imap.Message) bool {
+		switch decide(newSummary(folder, msg)) {
+		case Delete:
+			delUIDs.AddNum(msg.Uid)
+			res.Deleted++
+		case Trash:
+			if moveErr != nil {
+				trashErr = moveErr
+				return false
+			}
+			trashUIDs.AddNum(msg.Uid)
+			res.Trashed++
+		case Stop:
+			res.Stopped = true
+			return false
+		default:
+			res.Kept++
+		}
+
+		return true
+	})
+	if err == nil {
+		err = trashErr
+	}
+	if err != nil {
+		return TriageResult{}, err
+	}
+
+	if !trashUIDs.Empty() {
+		trash, err := trashFolder(b)
+		if err != nil {
+			return res, err
+		}
+
+		if !skipMutation(b, "move", trashUIDs, "to", trash) {
+			invalidateSelected(b)
+			if err := conn(b).UidMove(trashUIDs, string(trash)); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	if !delUIDs.Empty() {
+		if _, err := deleteMessagesPermanently(b, delUIDs); err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
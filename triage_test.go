@@ -0,0 +1,38 @@
+package inbox
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+func TestTriageTrashWithoutMoveCapability(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "delete me", time.Now())
+	s.addMessage(t, "Archive", "b@example.com", "trash me", time.Now())
+	b := s.dial(t)
+	if supports(b, "MOVE") {
+		t.Skip("test server supports MOVE")
+	}
+
+	decide := func(s MessageSummary) TriageAction {
+		if s.Subject == "trash me" {
+			return Trash
+		}
+		return Delete
+	}
+	want := ErrCapabilityMissing{Capability: "MOVE"}
+	if _, err := b.Triage("Archive", criteria.All(), decide); !errors.Is(err, want) {
+		t.Errorf("Triage with Trash without MOVE: err %v, want %v", err, want)
+	}
+	if n := len(s.mailbox(t, "Archive").Messages); n != 2 {
+		t.Errorf("Archive holds %d messages, want 2 with no decision applied", n)
+	}
+
+	res, err := b.Triage("Archive", criteria.All(), func(MessageSummary) TriageAction { return Delete })
+	if err != nil || res.Deleted != 2 {
+		t.Errorf("Triage deleting only: Deleted %d, err %v, want 2 without MOVE", res.Deleted, err)
+	}
+}
@@ -0,0 +1,68 @@
+package inbox
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Batzi1337/go-imapcleaner/criteria"
+)
+
+// Another client expunging a message shifts the sequence numbers of all later ones without this session
+// noticing, the messages found must still be the ones deleted.
+func TestDeleteAfterConcurrentExpunge(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	s.addMessage(t, "Archive", "b@example.com", "second", time.Now())
+	third := s.addMessage(t, "Archive", "c@example.com", "third", time.Now())
+	b := s.dial(t)
+
+	uids, err := b.Find("Archive", criteria.FromAny("c@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uids) != 1 || uids[0] != third {
+		t.Fatalf("Find = %v, want [%d]", uids, third)
+	}
+
+	other := s.dial(t)
+	if _, err := other.Delete(true, "Archive", criteria.FromAny("a@example.com")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := b.Delete(true, "Archive", criteria.ByUIDs(uids...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Deleted != 1 {
+		t.Errorf("Deleted = %d, want 1", res.Deleted)
+	}
+
+	mbox := s.mailbox(t, "Archive")
+	if len(mbox.Messages) != 1 || mbox.Messages[0].Uid == third {
+		t.Errorf("remaining messages = %d, want only the second one", len(mbox.Messages))
+	}
+}
+
+func TestDeleteFromAddressByUID(t *testing.T) {
+	s := newTestServer(t)
+	s.addMessage(t, "Archive", "a@example.com", "first", time.Now())
+	second := s.addMessage(t, "Archive", "b@example.com", "second", time.Now())
+	s.addMessage(t, "Archive", "a@example.com", "third", time.Now())
+	b := s.dial(t)
+	s.log.Reset()
+
+	if err := b.DeleteMessagesInFolderFromAddress(true, "Archive", "a@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range s.commands() {
+		if cmd == "STORE" || cmd == "FETCH" {
+			t.Errorf("sent %s addressed by sequence numbers, want UID %s", cmd, cmd)
+		}
+	}
+
+	mbox := s.mailbox(t, "Archive")
+	if len(mbox.Messages) != 1 || mbox.Messages[0].Uid != second {
+		t.Errorf("remaining messages = %d, want only the second one", len(mbox.Messages))
+	}
+}
@@ -0,0 +1,42 @@
+package inbox
+
+import (
+	"fmt"
+
+	"github.com/emersion/go-imap"
+)
+
+// WithVerifyAfterDelete searches the folder again after every expunge and fails with ErrVerificationFailed if
+// any of the deleted messages still exist. This catches servers silently ignoring EXPUNGE or flags which
+// didn't stick, at the cost of an extra round trip.
+func WithVerifyAfterDelete(verify bool) Option {
+	return func(i *Inbox) {
+		i.verify = verify
+	}
+}
+
+// ErrVerificationFailed is returned when messages are still in the folder after they were expunged.
+type ErrVerificationFailed struct {
+	// Residual are the UIDs of the messages which still exist.
+	Residual []uint32
+}
+
+func (e ErrVerificationFailed) Error() string {
+	return fmt.Sprintf("inbox: %d messages still exist after expunge", len(e.Residual))
+}
+
+// verifyDeleted searches the selected folder for the UIDs which should have been expunged.
+func verifyDeleted(b *Inbox, delUIDs *imap.SeqSet) error {
+	search := imap.NewSearchCriteria()
+	search.Uid = delUIDs
+	residual, err := conn(b).UidSearch(search)
+	if err != nil {
+		return fmt.Errorf("inbox: verify deletion: %w", err)
+	}
+
+	if len(residual) > 0 {
+		return ErrVerificationFailed{Residual: residual}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package inbox
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emersion/go-imap"
+)
+
+// Well-known folders can be passed wherever a Folder is expected. They are resolved to the folder of the account
+// with that use by ResolveFolder.
+const (
+	WellKnownTrash   Folder = imap.TrashAttr
+	WellKnownJunk    Folder = imap.JunkAttr
+	WellKnownSent    Folder = imap.SentAttr
+	WellKnownDrafts  Folder = imap.DraftsAttr
+	WellKnownArchive Folder = imap.ArchiveAttr
+)
+
+// wellKnown reports whether the folder is one of the well-known folders.
+func wellKnown(folder Folder) bool {
+	switch folder {
+	case WellKnownTrash, WellKnownJunk, WellKnownSent, WellKnownDrafts, WellKnownArchive:
+		return true
+	}
+
+	return false
+}
+
+// ResolveFolder returns the folder of the account a well-known folder like WellKnownJunk stands for, other folders
+// are returned as they are. The SPECIAL-USE attribute is preferred, then the localized names known for the
+// provider and common names are tried. The result is kept for the lifetime of the connection.
+func (b *Inbox) ResolveFolder(folder Folder) (Folder, error) {
+	defer operation(b)()
+	if !wellKnown(folder) {
+		return folder, nil
+	}
+
+	if resolved, ok := b.resolved[folder]; ok {
+		return resolved, nil
+	}
+
+	mailboxes, err := listMailboxes(b)
+	if err != nil {
+		return "", err
+	}
+
+	candidates := specialFolderCandidates(b, string(folder))
+	resolved := specialFolder(mailboxes, string(folder), candidates)
+	if resolved == "" {
+		return "", fmt.Errorf("%w: no %s folder, tried the attribute and %s", ErrFolderNotFound, folder, strings.Join(candidates, ", "))
+	}
+
+	if b.resolved == nil {
+		b.resolved = make(map[Folder]Folder)
+	}
+	b.resolved[folder] = resolved
+
+	return resolved, nil
+}